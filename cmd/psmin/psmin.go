@@ -60,32 +60,8 @@ func main() {
 
 func scan(w io.Writer, r io.ReadCloser) error {
 	defer r.Close()
-	s := scanner.New(r)
-
-	var pastHead bool
-	var last scanner.Type
-	for s.Next() == nil {
-		cur := s.Type()
-		if cur == scanner.Comment {
-			if pastHead || !*keepLeading {
-				continue
-			}
-		} else {
-			pastHead = true
-		}
-		if scanner.NeedSpaceBetween(last, cur) {
-			if last == scanner.Comment {
-				io.WriteString(w, "\n")
-			} else {
-				io.WriteString(w, " ")
-			}
-		}
-		io.WriteString(w, s.Text())
-		last = cur
-	}
+	m := scanner.Minifier{KeepLeadingComments: *keepLeading}
+	err := m.Minify(r, w)
 	io.WriteString(w, "\n")
-	if s.Err() == io.EOF {
-		return nil
-	}
-	return s.Err()
+	return err
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/postscript/scanner"
+)
+
+func TestScan(t *testing.T) {
+	const input = `%!PS-Adobe-3.0
+%%Title: test
+/in { 72 mul } def % inches
+/ /a 559 moveto
+% trailing comment
+`
+	tests := []struct {
+		keep bool
+		want string
+	}{
+		{false, "/in{72 mul}def/ /a 559 moveto\n"},
+		{true, "%!PS-Adobe-3.0\n%%Title: test\n/in{72 mul}def/ /a 559 moveto\n"},
+	}
+	for _, test := range tests {
+		*keepLeading = test.keep
+		var buf strings.Builder
+		if err := scan(&buf, io.NopCloser(strings.NewReader(input))); err != nil {
+			t.Errorf("scan (keep=%v): unexpected error: %v", test.keep, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("scan (keep=%v):\n got %#q\nwant %#q", test.keep, got, test.want)
+		}
+
+		// Without leading comments, the output matches the library.
+		if !test.keep {
+			min, err := scanner.MinifyString(input)
+			if err != nil {
+				t.Errorf("MinifyString: unexpected error: %v", err)
+			} else if min+"\n" != buf.String() {
+				t.Errorf("MinifyString: got %#q, psmin wrote %#q", min, buf.String())
+			}
+		}
+	}
+	*keepLeading = false
+}
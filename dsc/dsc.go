@@ -0,0 +1,115 @@
+// Package dsc extracts Document Structuring Convention (DSC) comments from
+// PostScript source text.
+//
+// DSC comments are lines beginning with "%%", such as
+//
+//	%%BoundingBox: 0 0 612 792
+//
+// which describe the structure of a document without affecting its meaning.
+// They are conventionally gathered in a header ending with "%%EndComments".
+package dsc
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxLine is the maximum number of bytes of a line that are retained.  DSC
+// comments are limited to 255 bytes, so longer lines are not of interest.
+const maxLine = 1024
+
+// ExtractDSC reads PostScript source text from r and returns the values of the
+// DSC header comments, keyed by keyword without the leading "%%" or trailing
+// ":". For example, "%%Creator: (me)" maps "Creator" to "(me)".
+// Continuation lines beginning with "%%+" are appended to the preceding value,
+// separated by a space. A keyword with no value maps to "".
+//
+// The header ends at "%%EndComments", at the first "%%Page:" comment, or at
+// the first line that is not a comment. If a header value is "(atend)", the value given for the same
+// keyword later in the file, typically in the trailer, replaces it.
+//
+// In addition, each "%%Page:" comment in the file is recorded under the key
+// "Page N", where N counts pages in order from 1. For example, the second
+// page comment "%%Page: ii 2" maps "Page 2" to "ii 2".
+func ExtractDSC(r io.Reader) (map[string]string, error) {
+	br := bufio.NewReader(r)
+	out := make(map[string]string)
+
+	inHeader, last, pages := true, "", 0
+	for {
+		line, err := readLine(br)
+		if err == io.EOF {
+			return out, nil
+		} else if err != nil {
+			return out, err
+		}
+
+		if inHeader {
+			if line == "%%EndComments" || !strings.HasPrefix(line, "%") || strings.HasPrefix(line, "%%Page:") {
+				inHeader = false
+			} else if strings.HasPrefix(line, "%%+") && last != "" {
+				out[last] += " " + strings.TrimSpace(line[3:])
+				continue
+			} else if key, val, ok := parseComment(line); ok {
+				if _, seen := out[key]; !seen {
+					out[key] = val
+				}
+				last = key
+				continue
+			}
+		}
+		if key, val, ok := parseComment(line); !ok {
+			continue
+		} else if key == "Page" {
+			pages++
+			out["Page "+strconv.Itoa(pages)] = val
+		} else if out[key] == "(atend)" {
+			out[key] = val
+		}
+	}
+}
+
+// parseComment parses a DSC comment line into a keyword and value, and
+// reports whether line is a DSC comment.
+func parseComment(line string) (key, value string, ok bool) {
+	if !strings.HasPrefix(line, "%%") || strings.HasPrefix(line, "%%+") {
+		return "", "", false
+	}
+	key, value, _ = strings.Cut(line[2:], ":")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(value), true
+}
+
+// readLine reads a line terminated by LF, CR, or CRLF from r, and returns its
+// contents without the terminator. Only the first maxLine bytes of a longer
+// line are returned. It returns io.EOF only if no bytes remain.
+func readLine(r *bufio.Reader) (string, error) {
+	var buf []byte
+	var n int
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF && n > 0 {
+			return string(buf), nil
+		} else if err != nil {
+			return "", err
+		}
+		n++
+		switch b {
+		case '\n':
+			return string(buf), nil
+		case '\r':
+			if c, err := r.ReadByte(); err == nil && c != '\n' {
+				r.UnreadByte()
+			}
+			return string(buf), nil
+		}
+		if len(buf) < maxLine {
+			buf = append(buf, b)
+		}
+	}
+}
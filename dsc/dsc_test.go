@@ -0,0 +1,84 @@
+package dsc
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExtractDSC(t *testing.T) {
+	f, err := os.Open("testdata/sample.eps")
+	if err != nil {
+		t.Fatalf("Opening test input: %v", err)
+	}
+	defer f.Close()
+
+	got, err := ExtractDSC(f)
+	if err != nil {
+		t.Fatalf("ExtractDSC: unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"Creator":       "(postscript test suite)",
+		"Title":         "(Sample figure)",
+		"CreationDate":  "(2026-10-16)",
+		"BoundingBox":   "0 0 288 144",
+		"DocumentFonts": "Helvetica Times-Roman Courier",
+		"Pages":         "2",
+		"Orientation":   "Portrait",
+		"Page 1":        "1 1",
+		"Page 2":        "ii 2",
+	}
+	for key, val := range want {
+		if g, ok := got[key]; !ok {
+			t.Errorf("Key %q: missing, want %q", key, val)
+		} else if g != val {
+			t.Errorf("Key %q: got %q, want %q", key, g, val)
+		}
+	}
+	for key, val := range got {
+		if _, ok := want[key]; !ok {
+			t.Errorf("Unexpected key %q = %q", key, val)
+		}
+	}
+}
+
+func TestExtractDSCLines(t *testing.T) {
+	tests := []struct {
+		input string
+		want  map[string]string
+	}{
+		{"", map[string]string{}},
+		{"%!PS\n/a 1 def\n%%Creator: late\n", map[string]string{}},
+		{"%!PS\r%%Creator: cr\r%%Title: x\r\n", map[string]string{"Creator": "cr", "Title": "x"}},
+		{"%%Title: first\n%%Title: second\n", map[string]string{"Title": "first"}},
+		{"%%Title:no-space", map[string]string{"Title": "no-space"}},
+		{"%%+ orphan\n%%: empty\n", map[string]string{}},
+
+		// Without %%EndComments, the header ends at the first page.
+		{"%!PS-Adobe-3.0\n%%Title: t\n%%Page: 1 1\n%%Page: 2 2\n", map[string]string{
+			"Title":  "t",
+			"Page 1": "1 1",
+			"Page 2": "2 2",
+		}},
+		{"%%Creator: " + strings.Repeat("x", 2*maxLine) + "\n%%Title: t\n", map[string]string{
+			"Creator": strings.Repeat("x", maxLine-len("%%Creator: ")),
+			"Title":   "t",
+		}},
+	}
+	for _, test := range tests {
+		got, err := ExtractDSC(strings.NewReader(test.input))
+		if err != nil {
+			t.Errorf("ExtractDSC(%#q): unexpected error: %v", test.input, err)
+			continue
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("ExtractDSC(%#q): got %q, want %q", test.input, got, test.want)
+			continue
+		}
+		for key, val := range test.want {
+			if got[key] != val {
+				t.Errorf("ExtractDSC(%#q): key %q: got %q, want %q", test.input, key, got[key], val)
+			}
+		}
+	}
+}
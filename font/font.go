@@ -0,0 +1,78 @@
+// Package font provides metrics for the 14 standard PostScript fonts.
+//
+// The metrics are taken from the Adobe Font Metrics (AFM) files for the
+// fonts. Character widths are provided for the printable ASCII range, codes
+// 0x20 through 0x7e. For the text fonts these are codes in StandardEncoding,
+// which matches ASCII except that 0x27 is quoteright and 0x60 is quoteleft.
+// For Symbol and ZapfDingbats they are codes in the font's built-in encoding.
+package font
+
+import "sort"
+
+const (
+	firstChar = 0x20 // the lowest character code with a recorded width
+	lastChar  = 0x7e // the highest character code with a recorded width
+	numChars  = lastChar - firstChar + 1
+)
+
+// A Font records the metrics for a font. All values are in units of 1/1000 of
+// the font size.
+type Font struct {
+	Name      string       // the PostScript name of the font, e.g., "Helvetica"
+	Metrics   map[rune]int // character widths
+	Ascender  float64      // the height of ascenders above the baseline
+	Descender float64      // the depth of descenders, as a negative value
+	CapHeight float64      // the height of capital letters
+}
+
+// StringWidth returns the width in points of s set in f at the given point
+// size. Characters for which f has no metrics contribute no width.
+func (f *Font) StringWidth(s string, size float64) float64 {
+	var total int
+	for _, r := range s {
+		total += f.Metrics[r]
+	}
+	return float64(total) * size / 1000
+}
+
+// Get returns the metrics for the standard font with the given name, and
+// reports whether the font was found. The *Font is shared and must not be
+// modified by the caller.
+func Get(name string) (*Font, bool) {
+	f, ok := fonts[name]
+	return f, ok
+}
+
+// Names returns the names of the standard fonts, in lexicographic order.
+func Names() []string {
+	names := make([]string, 0, len(fonts))
+	for name := range fonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var fonts = make(map[string]*Font)
+
+type stdFont struct {
+	name                 string
+	widths               *[numChars]int
+	asc, desc, capHeight float64
+}
+
+func init() {
+	for _, sf := range stdFonts {
+		m := make(map[rune]int, numChars)
+		for i, w := range sf.widths {
+			m[rune(firstChar+i)] = w
+		}
+		fonts[sf.name] = &Font{
+			Name:      sf.name,
+			Metrics:   m,
+			Ascender:  sf.asc,
+			Descender: sf.desc,
+			CapHeight: sf.capHeight,
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package font
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	names := Names()
+	if len(names) != 14 {
+		t.Errorf("Names(): got %d fonts, want 14", len(names))
+	}
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("Names() is not sorted: %q", names)
+	}
+	for _, name := range names {
+		f, ok := Get(name)
+		if !ok {
+			t.Errorf("Get(%q) failed", name)
+			continue
+		}
+		if f.Name != name {
+			t.Errorf("Get(%q): got name %q", name, f.Name)
+		}
+		if len(f.Metrics) != numChars {
+			t.Errorf("Get(%q): got %d widths, want %d", name, len(f.Metrics), numChars)
+		}
+		if f.Ascender <= 0 || f.Descender >= 0 {
+			t.Errorf("Get(%q): ascender %v, descender %v", name, f.Ascender, f.Descender)
+		}
+	}
+	if f, ok := Get("Comic-Sans"); ok {
+		t.Errorf("Get(Comic-Sans): got %+v, want not found", f)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	tests := []struct {
+		font string
+		char rune
+		want int
+	}{
+		{"Helvetica", ' ', 278},
+		{"Helvetica", 'A', 667},
+		{"Helvetica-Oblique", 'A', 667},
+		{"Helvetica-Bold", 'a', 556},
+		{"Helvetica-Bold", 'b', 611},
+		{"Times-Roman", 'A', 722},
+		{"Times-Roman", ' ', 250},
+		{"Times-Bold", 'W', 1000},
+		{"Times-Italic", 'A', 611},
+		{"Times-BoldItalic", 'm', 778},
+		{"Courier", 'i', 600},
+		{"Courier-BoldOblique", 'W', 600},
+		{"Helvetica", '\'', 222},      // quoteright
+		{"Helvetica", '`', 222},       // quoteleft
+		{"Helvetica-Bold", '\'', 278}, // quoteright
+		{"Times-Roman", '\'', 333},    // quoteright
+		{"Times-Italic", '`', 333},    // quoteleft
+		{"Courier", '\'', 600},
+		{"Symbol", 'a', 631}, // alpha
+		{"ZapfDingbats", ' ', 278},
+	}
+	for _, test := range tests {
+		f, ok := Get(test.font)
+		if !ok {
+			t.Errorf("Get(%q) failed", test.font)
+			continue
+		}
+		if got := f.Metrics[test.char]; got != test.want {
+			t.Errorf("%s width of %q: got %d, want %d", test.font, test.char, got, test.want)
+		}
+	}
+
+	h, _ := Get("Helvetica")
+	if h.CapHeight != 718 || h.Ascender != 718 || h.Descender != -207 {
+		t.Errorf("Helvetica: got cap %v asc %v desc %v, want 718, 718, -207", h.CapHeight, h.Ascender, h.Descender)
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	tests := []struct {
+		font, text string
+		size       float64
+		want       float64
+	}{
+		{"Helvetica", "", 12, 0},
+		{"Helvetica", "Hello", 1000, 722 + 556 + 222 + 222 + 556},
+		{"Times-Roman", "Hello", 1000, 722 + 444 + 278 + 278 + 500},
+		{"Courier", "twelve chars", 10, 72},
+		{"Helvetica", "café", 1000, 500 + 556 + 278}, // no width for é
+	}
+	for _, test := range tests {
+		f, _ := Get(test.font)
+		if got := f.StringWidth(test.text, test.size); got != test.want {
+			t.Errorf("%s StringWidth(%q, %v): got %v, want %v", test.font, test.text, test.size, got, test.want)
+		}
+	}
+}
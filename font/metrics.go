@@ -0,0 +1,108 @@
+package font
+
+// The metrics for the standard fonts, from the Adobe Font Metrics files.
+// Oblique variants share the widths of their upright counterparts.
+// Symbol and ZapfDingbats give no Ascender, Descender, or CapHeight, so the
+// vertical extent of their FontBBox is used instead, with no cap height.
+var stdFonts = []stdFont{
+	{"Courier", &courier, 629, -157, 562},
+	{"Courier-Bold", &courier, 629, -157, 562},
+	{"Courier-BoldOblique", &courier, 629, -157, 562},
+	{"Courier-Oblique", &courier, 629, -157, 562},
+	{"Helvetica", &helvetica, 718, -207, 718},
+	{"Helvetica-Bold", &helveticaBold, 718, -207, 718},
+	{"Helvetica-BoldOblique", &helveticaBold, 718, -207, 718},
+	{"Helvetica-Oblique", &helvetica, 718, -207, 718},
+	{"Symbol", &symbol, 1010, -293, 0},
+	{"Times-Bold", &timesBold, 683, -217, 676},
+	{"Times-BoldItalic", &timesBoldItalic, 683, -217, 669},
+	{"Times-Italic", &timesItalic, 683, -217, 653},
+	{"Times-Roman", &timesRoman, 683, -217, 662},
+	{"ZapfDingbats", &zapfDingbats, 820, -143, 0},
+}
+
+// Character widths for codes 0x20 through 0x7e, in units of 1/1000 em. For
+// the text fonts the codes are in StandardEncoding, which differs from ASCII
+// only at 0x27 (quoteright) and 0x60 (quoteleft); for Symbol and ZapfDingbats
+// they are the font's built-in encoding.
+
+var courier = [numChars]int{
+	600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, // 0x20-0x2f
+	600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, // 0x30-0x3f
+	600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, // 0x40-0x4f
+	600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, // 0x50-0x5f
+	600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, // 0x60-0x6f
+	600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, // 0x70-0x7e
+}
+
+var helvetica = [numChars]int{
+	278, 278, 355, 556, 556, 889, 667, 222, 333, 333, 389, 584, 278, 333, 278, 278, // 0x20-0x2f
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0x30-0x3f
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // 0x40-0x4f
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // 0x50-0x5f
+	222, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // 0x60-0x6f
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // 0x70-0x7e
+}
+
+var helveticaBold = [numChars]int{
+	278, 333, 474, 556, 556, 889, 722, 278, 333, 333, 389, 584, 278, 333, 278, 278, // 0x20-0x2f
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611, // 0x30-0x3f
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778, // 0x40-0x4f
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556, // 0x50-0x5f
+	278, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611, // 0x60-0x6f
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584, // 0x70-0x7e
+}
+
+var symbol = [numChars]int{
+	250, 333, 713, 500, 549, 833, 778, 439, 333, 333, 500, 549, 250, 549, 250, 278, // 0x20-0x2f
+	500, 500, 500, 500, 500, 500, 500, 500, 500, 500, 278, 278, 549, 549, 549, 444, // 0x30-0x3f
+	549, 722, 667, 722, 612, 611, 763, 603, 722, 333, 631, 722, 686, 889, 722, 722, // 0x40-0x4f
+	768, 741, 556, 592, 611, 690, 439, 768, 645, 795, 611, 333, 863, 333, 658, 500, // 0x50-0x5f
+	500, 631, 549, 549, 494, 439, 521, 411, 603, 329, 603, 549, 549, 576, 521, 549, // 0x60-0x6f
+	549, 521, 549, 603, 439, 576, 713, 686, 493, 686, 494, 480, 200, 480, 549, // 0x70-0x7e
+}
+
+var timesBold = [numChars]int{
+	250, 333, 555, 500, 500, 1000, 833, 333, 333, 333, 500, 570, 250, 333, 250, 278, // 0x20-0x2f
+	500, 500, 500, 500, 500, 500, 500, 500, 500, 500, 333, 333, 570, 570, 570, 500, // 0x30-0x3f
+	930, 722, 667, 722, 722, 667, 611, 778, 778, 389, 500, 778, 667, 944, 722, 778, // 0x40-0x4f
+	611, 778, 722, 556, 667, 722, 722, 1000, 722, 722, 667, 333, 278, 333, 581, 500, // 0x50-0x5f
+	333, 500, 556, 444, 556, 444, 333, 500, 556, 278, 333, 556, 278, 833, 556, 500, // 0x60-0x6f
+	556, 556, 444, 389, 333, 556, 500, 722, 500, 500, 444, 394, 220, 394, 520, // 0x70-0x7e
+}
+
+var timesBoldItalic = [numChars]int{
+	250, 389, 555, 500, 500, 833, 778, 333, 333, 333, 500, 570, 250, 333, 250, 278, // 0x20-0x2f
+	500, 500, 500, 500, 500, 500, 500, 500, 500, 500, 333, 333, 570, 570, 570, 500, // 0x30-0x3f
+	832, 667, 667, 667, 722, 667, 667, 722, 778, 389, 500, 667, 611, 889, 722, 722, // 0x40-0x4f
+	611, 722, 667, 556, 611, 722, 667, 889, 667, 611, 611, 333, 278, 333, 570, 500, // 0x50-0x5f
+	333, 500, 500, 444, 500, 444, 333, 500, 556, 278, 278, 500, 278, 778, 556, 500, // 0x60-0x6f
+	500, 500, 389, 389, 278, 556, 444, 667, 500, 444, 389, 348, 220, 348, 570, // 0x70-0x7e
+}
+
+var timesItalic = [numChars]int{
+	250, 333, 420, 500, 500, 833, 778, 333, 333, 333, 500, 675, 250, 333, 250, 278, // 0x20-0x2f
+	500, 500, 500, 500, 500, 500, 500, 500, 500, 500, 333, 333, 675, 675, 675, 500, // 0x30-0x3f
+	920, 611, 611, 667, 722, 611, 611, 722, 722, 333, 444, 667, 556, 833, 667, 722, // 0x40-0x4f
+	611, 722, 611, 500, 556, 722, 611, 833, 611, 556, 556, 389, 278, 389, 422, 500, // 0x50-0x5f
+	333, 500, 500, 444, 500, 444, 278, 500, 500, 278, 278, 444, 278, 722, 500, 500, // 0x60-0x6f
+	500, 500, 389, 389, 278, 500, 444, 667, 444, 444, 389, 400, 275, 400, 541, // 0x70-0x7e
+}
+
+var timesRoman = [numChars]int{
+	250, 333, 408, 500, 500, 833, 778, 333, 333, 333, 500, 564, 250, 333, 250, 278, // 0x20-0x2f
+	500, 500, 500, 500, 500, 500, 500, 500, 500, 500, 278, 278, 564, 564, 564, 444, // 0x30-0x3f
+	921, 722, 667, 667, 722, 611, 556, 722, 722, 333, 389, 722, 611, 889, 722, 722, // 0x40-0x4f
+	556, 722, 667, 556, 611, 722, 722, 944, 722, 722, 611, 333, 278, 333, 469, 500, // 0x50-0x5f
+	333, 444, 500, 444, 500, 444, 333, 500, 500, 278, 278, 500, 278, 778, 500, 500, // 0x60-0x6f
+	500, 500, 333, 389, 278, 500, 500, 722, 500, 500, 444, 480, 200, 480, 541, // 0x70-0x7e
+}
+
+var zapfDingbats = [numChars]int{
+	278, 974, 961, 974, 980, 719, 789, 790, 791, 690, 960, 939, 549, 855, 911, 933, // 0x20-0x2f
+	911, 945, 974, 755, 846, 762, 761, 571, 677, 763, 760, 759, 754, 494, 552, 537, // 0x30-0x3f
+	577, 692, 786, 788, 788, 790, 793, 794, 816, 823, 789, 841, 823, 833, 816, 831, // 0x40-0x4f
+	923, 744, 723, 749, 790, 792, 695, 776, 768, 792, 759, 707, 708, 682, 701, 826, // 0x50-0x5f
+	815, 789, 789, 707, 687, 696, 689, 786, 787, 713, 791, 785, 791, 873, 761, 762, // 0x60-0x6f
+	762, 759, 759, 892, 892, 788, 784, 438, 138, 277, 415, 392, 392, 668, 668, // 0x70-0x7e
+}
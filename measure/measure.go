@@ -0,0 +1,34 @@
+// Package measure estimates the width of text set in the standard PostScript
+// fonts.
+//
+// Exact measurement requires a PostScript interpreter, but for layout it is
+// often enough to sum the advance widths of the glyphs as given by the Adobe
+// Font Metrics (AFM) for the font. This package uses the metrics provided by
+// the font package, which cover the printable ASCII range.
+package measure
+
+import (
+	"fmt"
+
+	"github.com/creachadair/postscript/font"
+)
+
+// Fonts returns the names of the fonts for which widths are available.
+func Fonts() []string { return font.Names() }
+
+// EstimateWidth returns the approximate width in points of text set in the
+// named font at the given point size. It reports an error if the font is not
+// known, or if text contains a character for which the font has no width.
+// Kerning and ligatures are not taken into account.
+func EstimateWidth(text, fontName string, pointSize float64) (float64, error) {
+	f, ok := font.Get(fontName)
+	if !ok {
+		return 0, fmt.Errorf("unknown font %q", fontName)
+	}
+	for i, r := range text {
+		if _, ok := f.Metrics[r]; !ok {
+			return 0, fmt.Errorf("no width for %q at offset %d", r, i)
+		}
+	}
+	return f.StringWidth(text, pointSize), nil
+}
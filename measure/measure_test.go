@@ -0,0 +1,62 @@
+package measure
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestEstimateWidth(t *testing.T) {
+	tests := []struct {
+		text, font string
+		size       float64
+		want       float64
+	}{
+		{"", "Helvetica", 12, 0},
+		{"A", "Helvetica", 1, 0.667},
+		{"A", "Times-Roman", 1, 0.722},
+		{" ", "Helvetica", 1000, 278},
+		{" ", "Times-Roman", 1000, 250},
+		{"Hello", "Helvetica", 1000, 722 + 556 + 222 + 222 + 556},
+		{"Hello", "Times-Roman", 1000, 722 + 444 + 278 + 278 + 500},
+		{"anything at all", "Courier", 10, 15 * 6},
+		{"abg", "Symbol", 1000, 631 + 549 + 411}, // alpha beta gamma
+	}
+	for _, test := range tests {
+		got, err := EstimateWidth(test.text, test.font, test.size)
+		if err != nil {
+			t.Errorf("EstimateWidth(%q, %q, %v): unexpected error: %v", test.text, test.font, test.size, err)
+		} else if got != test.want {
+			t.Errorf("EstimateWidth(%q, %q, %v): got %v, want %v", test.text, test.font, test.size, got, test.want)
+		}
+	}
+}
+
+func TestEstimateWidthErrors(t *testing.T) {
+	tests := []struct {
+		text, font string
+	}{
+		{"abc", "NoSuchFont"},
+		{"tab\there", "Helvetica"},
+		{"café", "Times-Roman"},
+	}
+	for _, test := range tests {
+		got, err := EstimateWidth(test.text, test.font, 12)
+		if err == nil {
+			t.Errorf("EstimateWidth(%q, %q): got %v, wanted error", test.text, test.font, got)
+		} else {
+			t.Logf("EstimateWidth(%q, %q): got %v [OK]", test.text, test.font, err)
+		}
+	}
+}
+
+func TestFonts(t *testing.T) {
+	names := Fonts()
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("Fonts() is not sorted: %q", names)
+	}
+	for _, name := range names {
+		if _, err := EstimateWidth("x", name, 12); err != nil {
+			t.Errorf("Font %q: unexpected error: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package scanner_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/creachadair/postscript/scanner"
+	"github.com/creachadair/postscript/scantest"
+)
+
+func tok(typ scanner.Type, text string) scanner.Token {
+	return scanner.Token{Type: typ, Text: text}
+}
+
+func TestRawTokens(t *testing.T) {
+	const (
+		comment = scanner.Comment
+		str     = scanner.LitString
+		name    = scanner.Name
+		qname   = scanner.QuotedName
+		iname   = scanner.ImmediateName
+	)
+	tests := []struct {
+		input string
+		want  []scanner.Token
+	}{
+		// Empty or all-whitespace inputs should produce no tokens.
+		{"", nil},
+		{"   ", nil},
+		{"\t \t", nil},
+
+		// Comments should include their terminator.
+		{"% hello\n%% goodbye", []scanner.Token{tok(comment, "% hello\n"), tok(comment, "%% goodbye")}},
+
+		// Somewhat unusually, comments can end with form-feed.
+		{"% hello\f % world\n ", []scanner.Token{tok(comment, "% hello\f"), tok(comment, "% world\n")}},
+
+		// Comments can end with CR, LF, or a CRLF pair.
+		{"% comment\rnext", []scanner.Token{tok(comment, "% comment\r"), tok(name, "next")}},
+		{"% comment\r\nnext", []scanner.Token{tok(comment, "% comment\r\n"), tok(name, "next")}},
+		{"% comment\nnext", []scanner.Token{tok(comment, "% comment\n"), tok(name, "next")}},
+		{"% a\r\r% b\r", []scanner.Token{tok(comment, "% a\r"), tok(comment, "% b\r")}},
+
+		// Various name-shaped things.
+		{"a /b //c $d ", []scanner.Token{tok(name, "a"), tok(qname, "/b"), tok(iname, "//c"), tok(name, "$d")}},
+		{"-3\n2.5e9\n 2#1101", []scanner.Token{
+			tok(scanner.Decimal, "-3"), tok(scanner.Real, "2.5e9"), tok(scanner.Radix, "2#1101"),
+		}},
+
+		// Only "/", "<", and ">" may be doubled at the start of a token.
+		{"667 aab 1100 //x >>", []scanner.Token{
+			tok(scanner.Decimal, "667"), tok(name, "aab"), tok(scanner.Decimal, "1100"),
+			tok(iname, "//x"), tok(name, ">>"),
+		}},
+
+		// Slashes should terminate name processing except at the start.
+		{"eat/your//veggies", []scanner.Token{tok(name, "eat"), tok(qname, "/your"), tok(iname, "//veggies")}},
+
+		// Self-delimiting names should delimit themselves.
+		{"{a<<b>>c[d]}", []scanner.Token{
+			tok(scanner.Left, "{"), tok(name, "a"), tok(name, "<<"), tok(name, "b"), tok(name, ">>"),
+			tok(name, "c"), tok(name, "["), tok(name, "d"), tok(name, "]"), tok(scanner.Right, "}"),
+		}},
+
+		// String literals preserve whitespace inside them.
+		{"(a\nb\nc d)", []scanner.Token{tok(str, "(a\nb\nc d)")}},
+
+		// String literals respect balanced nested quotations, and unbalanced
+		// nested quotations can be quoted. Note that at this point we have not
+		// done any decoding so all the escapes are still there.
+		{" (a (b c)\n d)\n", []scanner.Token{tok(str, "(a (b c)\n d)")}},
+		{`(abc\(def)`, []scanner.Token{tok(str, `(abc\(def)`)}},
+		{`(\)\\\))`, []scanner.Token{tok(str, `(\)\\\))`)}},
+
+		// Hex and A85 literals.
+		{"<66 6f 6f><~  AoDS  ~>", []scanner.Token{
+			tok(scanner.HexString, "<66 6f 6f>"), tok(scanner.A85String, "<~  AoDS  ~>"),
+		}},
+	}
+	for _, test := range tests {
+		scantest.AssertTokens(t, test.input, test.want)
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		// String literals.
+		{"(a (b) c)", []string{"a (b) c"}},
+		{"(a \\(b c)", []string{"a (b c"}},
+		{"  (a\n b c\\) def)", []string{"a\n b c) def"}},
+		{"(ab\\\ncd\\\ref\\\r\ngh)", []string{"abcdefgh"}},
+
+		// Hex literals.
+		{"<> <  > <50> <5>", []string{"", "", "P", "P"}},
+		{"<66 6f 6f>", []string{"foo"}},
+		{"<32 31 3>", []string{"210"}},
+
+		// A85 literals.
+		{"<~~> <~  ~> <~ AoDS ~>", []string{"", "", "foo"}},
+
+		// The "z" abbreviation for four zero bytes, alone and in combination.
+		{"<~z~>", []string{"\x00\x00\x00\x00"}},
+		{"<~zzz~>", []string{strings.Repeat("\x00", 12)}},
+		{"<~ z AoDS ~>", []string{"\x00\x00\x00\x00foo"}},
+		{"<~zFCfN8z~>", []string{"\x00\x00\x00\x00test\x00\x00\x00\x00"}},
+
+		// Names and punctuation.
+		{"alpha/bravo charlie //xray", []string{"alpha", "bravo", "charlie", "xray"}},
+		{"[full /plate (and) {packing}]<<steel>>", []string{
+			"[", "full", "plate", "and", "{", "packing", "}", "]", "<<", "steel", ">>",
+		}},
+
+		// Comments.
+		{"% foo\n% bar\f\n% baz\n ", []string{"foo", "bar", "baz"}},
+		{"%% I am the lizard king\n\n% I can do anything\f\n", []string{
+			"I am the lizard king", "I can do anything",
+		}},
+
+		// Numbers.
+		{"1.3 .0 2#1101 6.67e-11", []string{"1.3", ".0", "2#1101", "6.67e-11"}},
+	}
+	for _, test := range tests {
+		scantest.AssertDecodes(t, test.input, test.want)
+	}
+}
+
+func TestScanErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		offset int
+	}{
+		// Unterminated string literals.
+		{`(unterminated string`, 0},
+		{`(`, 0},
+		{`<ac 9e 30`, 0},
+		{`<`, 0},
+		{`<~ apple pie `, 0},
+		{`<~`, 0},
+		{`ok so far (`, 10},
+
+		// Invalid contents.
+		{`< BOGUS HEX>`, 0},
+		{`<~ xxx is not legal A85 xxx ~>`, 0},
+		{`<~ all good so far oops ~ ~>`, 0},
+		{"/x\n<~ oops ~ ~>", 3},
+	}
+	for _, test := range tests {
+		scantest.AssertError(t, test.input, test.offset)
+	}
+}
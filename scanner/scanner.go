@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
@@ -16,11 +17,30 @@ import (
 // A Scanner consumes PostScript tokens from an input stream.  Use the Next
 // method to parse tokens sequentially from the input.
 type Scanner struct {
+	// MaxTokenBytes, if positive, is the maximum length in bytes of a single
+	// token. Next reports a *ScanError if a token exceeds this length.
+	// If zero, there is no limit.
+	MaxTokenBytes int
+
+	// NormalizeTokens, if true, causes Text to report a normalized form of
+	// some tokens: Radix digits are converted to upper case, line
+	// continuations are removed from string literals, and whitespace is
+	// removed from hex strings. This does not change the decoded value of
+	// any token, nor the offsets reported by Pos and End.
+	NormalizeTokens bool
+
 	input    *bufio.Reader // the unconsumed input
 	text     *bytes.Buffer // the text of the current token
 	err      error         // the last non-nil error reported
 	token    Type          // the type of the current token
 	pos, end int
+	read     int // the number of input bytes consumed
+	count    int // the number of tokens successfully scanned
+	index    int // the 0-based index of the current token
+
+	recover bool    // whether to resume scanning after an error
+	errs    []error // errors reported in recovery mode
+	level   int     // the language level to accept, or 0 for any
 }
 
 // Type denotes the lexical type of a token.
@@ -45,6 +65,13 @@ const (
 	numTypes
 )
 
+// A Token records the type, text, and location of a single token.
+type Token struct {
+	Type     Type   // the lexical type of the token
+	Text     string // the literal text of the token
+	Pos, End int    // the starting and ending byte offsets of the token
+}
+
 // New constructs a *Scanner that reads from r.
 func New(r io.Reader) *Scanner {
 	return &Scanner{
@@ -53,6 +80,90 @@ func New(r io.Reader) *Scanner {
 	}
 }
 
+// NewLevel1 constructs a *Scanner that reads from r and accepts only language
+// level 1 input. It is shorthand for New(r).SetLevel(1).
+func NewLevel1(r io.Reader) *Scanner { return New(r).SetLevel(1) }
+
+// NewFromBytes constructs a *Scanner that reads from b.
+func NewFromBytes(b []byte) *Scanner { return New(bytes.NewReader(b)) }
+
+// fileBufferSize is the size of the read buffer used by NewFromFile.
+const fileBufferSize = 64 << 10
+
+// NewFromFile opens the file at path and constructs a *Scanner that reads from
+// it. The caller is responsible for closing the file via the returned closer
+// when the scanner is no longer needed.
+func NewFromFile(path string) (*Scanner, io.Closer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &Scanner{
+		input: bufio.NewReaderSize(f, fileBufferSize),
+		text:  bytes.NewBuffer(nil),
+	}, f, nil
+}
+
+// NewFromBufioScanner constructs a *Scanner that reads the lines produced by
+// bs. A newline is restored after each line, so tokens such as string
+// literals that span multiple lines are scanned intact. Since bs removes line
+// terminators, a CRLF pair inside a string literal is read as a single LF.
+func NewFromBufioScanner(bs *bufio.Scanner) *Scanner {
+	return New(&lineReader{bs: bs})
+}
+
+// lineReader adapts a *bufio.Scanner to an io.Reader, following each line
+// with a newline.
+type lineReader struct {
+	bs   *bufio.Scanner
+	line []byte // the current line, including its newline
+	pos  int    // the offset of the unread portion of line
+}
+
+func (r *lineReader) Read(data []byte) (int, error) {
+	for r.pos == len(r.line) {
+		if !r.bs.Scan() {
+			if err := r.bs.Err(); err != nil {
+				return 0, err
+			}
+			return 0, io.EOF
+		}
+		r.line = append(append(r.line[:0], r.bs.Bytes()...), '\n')
+		r.pos = 0
+	}
+	n := copy(data, r.line[r.pos:])
+	r.pos += n
+	return n, nil
+}
+
+// Reset discards the state of s and resets it to read from r.
+func (s *Scanner) Reset(r io.Reader) {
+	s.input.Reset(r)
+	s.text.Reset()
+	s.err = nil
+	s.token = Invalid
+	s.pos, s.end, s.read = 0, 0, 0
+	s.count, s.index = 0, 0
+	s.errs = nil
+}
+
+// ContinueOnError puts s into recovery mode and returns s.  In recovery mode,
+// when Next reports a *ScanError, s discards the rest of the malformed token
+// so that a subsequent call to Next can continue with the input that follows.
+// The errors are recorded and can be retrieved with the Errors method.
+func (s *Scanner) ContinueOnError() *Scanner { s.recover = true; return s }
+
+// SetLevel sets the PostScript language level accepted by s, and returns s.
+// At level 1, Next reports a *ScanError for a name that is a known level 2
+// or later operator, such as "<<" or "setcolorspace". At any other level, all
+// names are accepted. The check is based on a fixed list of common operators
+// and is not exhaustive.
+func (s *Scanner) SetLevel(level int) *Scanner { s.level = level; return s }
+
+// Errors returns the scan errors reported since s was created or last reset,
+// in the order they occurred. Errors are recorded only in recovery mode.
+func (s *Scanner) Errors() []error { return s.errs }
+
 var (
 	// Floating-point notation: -.002 34.5 -3.62 123.6e10 1.0E-5 1E6 -1. 0.0
 	numReal = regexp.MustCompile(`^-?(\d+([eE][-+]?\d+)|(\d*\.\d+|\d+\.)([eE][-+]?\d+)?)$`)
@@ -64,6 +175,9 @@ var (
 	// This expression allows invalid radix/digit combinations.
 	numRadix = regexp.MustCompile(`^\d+#[0-9A-Za-z]+$`)
 
+	// Magic line version: %!PS-Adobe-3.0
+	magicVersion = regexp.MustCompile(`^%!PS-Adobe-(\d+)\.(\d+)`)
+
 	// TODO: It's not clear how, or whether, sign is supposed to be accepted on
 	// radix numbers.  I followed ghostscript here in omitting it, but the
 	// reference manual is not explicit on the topic.
@@ -73,9 +187,33 @@ var (
 // token is available. If no further tokens are available, it returns io.EOF;
 // otherwise it reports what went wrong.
 func (s *Scanner) Next() error {
+	err := s.next()
+	s.end = s.read
+	if err == nil && s.level == 1 && s.token == Name && level2Names[s.Text()] {
+		// The token was read completely, so there is no need to resync.
+		err = s.failf("%s is not supported in language level 1", s.Text())
+		if s.recover {
+			s.errs = append(s.errs, err)
+		}
+		return err
+	}
+	if err == nil {
+		if s.NormalizeTokens {
+			s.normalize()
+		}
+		s.index = s.count
+		s.count++
+	} else if _, ok := err.(*ScanError); ok && s.recover {
+		s.errs = append(s.errs, err)
+		s.resync()
+	}
+	return err
+}
+
+func (s *Scanner) next() error {
 	// Reset state
 	s.text.Reset()
-	s.pos = s.end
+	s.pos = s.read
 	s.token = Invalid
 	s.err = nil
 
@@ -84,7 +222,7 @@ func (s *Scanner) Next() error {
 		if err != nil {
 			return s.seterr(err)
 		} else if isSpace(b) {
-			s.pos = s.end
+			s.pos = s.read
 			continue // skip whitespace
 		}
 
@@ -111,7 +249,7 @@ func (s *Scanner) Next() error {
 			// This might be different things, depending on what follows.
 			c, err := s.byte()
 			if err == io.EOF {
-				return s.seterr(errors.New("unterminated hex string"))
+				return s.failf("unterminated hex string")
 			} else if err != nil {
 				return s.seterr(err)
 			} else if c == '~' { // ascii85 literal
@@ -124,15 +262,153 @@ func (s *Scanner) Next() error {
 			}
 			return s.scanNamelike(b)
 
-		default:
+		case '/', '>':
+			// These may be doubled: "//name" and ">>".
 			return s.scanNamelike(b)
+
+		default:
+			return s.scanNamelike(0)
+		}
+	}
+}
+
+// ScanFunc calls Next repeatedly, passing s to fn after each token is read.
+// If fn reports an error, scanning stops and ScanFunc returns that error.
+// Otherwise ScanFunc returns nil at the end of input, or the error from Next.
+func (s *Scanner) ScanFunc(fn func(*Scanner) error) error {
+	for {
+		if err := s.Next(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
 		}
 	}
 }
 
+// All calls yield with each token read from s, in order, until yield returns
+// false or the input is exhausted. If scanning fails, All calls yield with a
+// zero Token and the error, and then stops. All does not reset s, so a later
+// call continues from where the previous one stopped. With Go 1.23 or later,
+// All can be used as a range function:
+//
+//	for tok, err := range s.All {
+//		// ...
+//	}
+func (s *Scanner) All(yield func(Token, error) bool) {
+	for {
+		if err := s.Next(); err == io.EOF {
+			return
+		} else if err != nil {
+			yield(Token{}, err)
+			return
+		}
+		tok := Token{Type: s.token, Text: s.Text(), Pos: s.pos, End: s.end}
+		if !yield(tok, nil) {
+			return
+		}
+	}
+}
+
+// ConsumeWhitespace reads and discards whitespace from the input up to the
+// next non-whitespace byte or the end of input. It returns the number of bytes
+// discarded, and any error other than io.EOF from reading the input.
+// ConsumeWhitespace does not change the current token.
+func (s *Scanner) ConsumeWhitespace() (int, error) {
+	var n int
+	for {
+		b, err := s.byte()
+		if err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, err
+		} else if !isSpace(b) {
+			s.unget()
+			return n, nil
+		}
+		n++
+	}
+}
+
+// ReadHexString reads a hex string literal such as <666f6f> from the input,
+// skipping any leading whitespace, and returns its decoded bytes. A malformed
+// literal, or one longer than MaxTokenBytes, is reported as a *ScanError.
+// ReadHexString does not change the current token.
+func (s *Scanner) ReadHexString() ([]byte, error) {
+	if _, err := s.ConsumeWhitespace(); err != nil {
+		return nil, err
+	}
+	pos := s.read
+	fail := func(msg string, args ...any) error {
+		return &ScanError{Pos: pos, Message: fmt.Sprintf(msg, args...)}
+	}
+	if b, err := s.byte(); err == io.EOF {
+		return nil, fail("missing hex string")
+	} else if err != nil {
+		return nil, err
+	} else if b != '<' {
+		s.unget()
+		return nil, fail("invalid hex string start %q", b)
+	}
+
+	var buf []byte
+	var cur byte
+	var odd bool
+	for n := 1; ; {
+		b, err := s.byte()
+		if err == io.EOF {
+			return nil, fail("unterminated hex string")
+		} else if err != nil {
+			return nil, err
+		}
+		if n++; s.MaxTokenBytes > 0 && n > s.MaxTokenBytes {
+			return nil, fail("token exceeds maximum size of %d bytes", s.MaxTokenBytes)
+		} else if b == '>' {
+			break
+		} else if isHex(b) {
+			cur = 16*cur + hexVal(b)
+			odd = !odd
+			if !odd {
+				buf = append(buf, cur)
+				cur = 0
+			}
+		} else if !isSpace(b) {
+			return nil, fail("%s", badHexMessage(b, s.read-1))
+		}
+	}
+	if odd {
+		buf = append(buf, 16*cur) // x becomes x0
+	}
+	return buf, nil
+}
+
 // Err returns the last error reported by Next.
 func (s *Scanner) Err() error { return s.err }
 
+// ErrAt returns the last error reported by Next if it is a *ScanError, or nil.
+// In particular, ErrAt returns nil if there is no error, at io.EOF, or if the
+// error came from reading the underlying input.
+func (s *Scanner) ErrAt() *ScanError {
+	var se *ScanError
+	if errors.As(s.err, &se) {
+		return se
+	}
+	return nil
+}
+
+// A ScanError is reported by Next when the input is not well-formed.
+type ScanError struct {
+	Pos     int    // the starting offset of the token containing the error
+	Message string // a description of the problem
+}
+
+// Error satisfies the error interface.
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("offset %d: %s", e.Pos, e.Message)
+}
+
 // Type reports the lexical type of the current token.
 func (s *Scanner) Type() Type { return s.token }
 
@@ -145,6 +421,44 @@ func (s *Scanner) Pos() int { return s.pos }
 // End returns the ending byte offset of the current token in the input.
 func (s *Scanner) End() int { return s.end }
 
+// Count returns the number of tokens successfully scanned by Next since s was
+// created or last reset.
+func (s *Scanner) Count() int { return s.count }
+
+// TokenIndex returns the 0-based index of the current token among all the
+// tokens scanned since s was created or last reset. Before the first token is
+// read, TokenIndex returns 0.
+func (s *Scanner) TokenIndex() int { return s.index }
+
+// BytesRead returns the number of bytes of input consumed by s since it was
+// created or last reset.
+func (s *Scanner) BytesRead() int { return s.read }
+
+// IsMagicLine reports whether the current token is a comment beginning with
+// "%!", such as the "%!PS-Adobe-3.0" line that starts a PostScript file.
+func (s *Scanner) IsMagicLine() bool {
+	return s.token == Comment && strings.HasPrefix(s.Text(), "%!")
+}
+
+// PSVersion reports the major and minor version numbers from a magic line of
+// the form "%!PS-Adobe-M.N". It returns ok == false if the current token is
+// not a magic line or does not include a version number.
+func (s *Scanner) PSVersion() (major, minor int, ok bool) {
+	if !s.IsMagicLine() {
+		return 0, 0, false
+	}
+	m := magicVersion.FindStringSubmatch(s.Text())
+	if m == nil {
+		return 0, 0, false
+	}
+	major, err1 := strconv.Atoi(m[1])
+	minor, err2 := strconv.Atoi(m[2])
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
 // ErrInvalidFormat is reported when decoding a token value that does not match
 // the specified result format.
 var ErrInvalidFormat = errors.New("invalid format")
@@ -196,8 +510,15 @@ func (s *Scanner) Float64() (float64, error) {
 	}
 }
 
-// String returns the decoded value of the current token as a string. This has
-// different effects depending on the type:
+// String returns the decoded value of the current token as a string, as
+// described by DecodeToken.
+func (s *Scanner) String() string { return DecodeToken(s.token, s.Text()) }
+
+// Decoded returns the decoded value of t, as described by DecodeToken.
+func (t Token) Decoded() string { return DecodeToken(t.Type, t.Text) }
+
+// DecodeToken returns the decoded value of a token with the given type and
+// text. This has different effects depending on the type:
 //
 // Numeric tokens and punctuation are returned as-written.
 //
@@ -207,30 +528,167 @@ func (s *Scanner) Float64() (float64, error) {
 //
 // Comment tokens are stripped of all leading "%" as well as any leading and
 // trailing whitespace that remains after doing so.
-func (s *Scanner) String() string {
-	switch s.token {
+//
+// The text should be well-formed for the type, as reported by a Scanner. If a
+// string literal is missing its quotes, DecodeToken returns "".
+func DecodeToken(typ Type, text string) string {
+	switch typ {
 	case Decimal, Left, Name, Radix, Real, Right:
-		return s.Text()
+		return text
 	case QuotedName:
-		return strings.TrimPrefix(s.Text(), "/")
+		return strings.TrimPrefix(text, "/")
 	case ImmediateName:
-		return strings.TrimPrefix(s.Text(), "//")
+		return strings.TrimPrefix(text, "//")
 	case Comment:
-		return strings.TrimSpace(strings.TrimLeft(s.Text(), "%"))
+		return strings.TrimSpace(strings.TrimLeft(text, "%"))
 	case LitString:
-		text := s.Text()
-		unquoted := text[1 : len(text)-1] // remove outer "(" and ")"
-		return decodeLiteral(unquoted)
+		if unquoted, ok := unquote(text, "(", ")"); ok {
+			return decodeLiteral(unquoted)
+		}
 	case HexString:
-		text := s.Text()
-		unquoted := text[1 : len(text)-1] // remove outer "<" and ">"
-		return decodeHex(unquoted)
+		if unquoted, ok := unquote(text, "<", ">"); ok {
+			return decodeHex(unquoted)
+		}
 	case A85String:
-		text := s.Text()
-		unquoted := text[2 : len(text)-2] // remove outer "<~" and "~>"
-		return decodeA85(unquoted)
+		if unquoted, ok := unquote(text, "<~", "~>"); ok {
+			return decodeA85(unquoted)
+		}
+	}
+	return ""
+}
+
+// unquote removes the given opening and closing quotes from text, and reports
+// whether both were present.
+func unquote(text, open, close string) (string, bool) {
+	if len(text) < len(open)+len(close) || !strings.HasPrefix(text, open) || !strings.HasSuffix(text, close) {
+		return "", false
+	}
+	return text[len(open) : len(text)-len(close)], true
+}
+
+// normalize rewrites the text of the current token in normal form.
+func (s *Scanner) normalize() {
+	var norm string
+	switch s.token {
+	case Radix:
+		base, digits, _ := strings.Cut(s.Text(), "#")
+		norm = base + "#" + strings.ToUpper(digits)
+	case LitString:
+		norm = removeContinuations(s.Text())
+	case HexString:
+		norm = strings.Map(func(r rune) rune {
+			if r < 0x80 && isSpace(byte(r)) {
+				return -1
+			}
+			return r
+		}, s.Text())
 	default:
-		return ""
+		return
+	}
+	s.text.Reset()
+	s.text.WriteString(norm)
+}
+
+// resync discards the text of the current token and skips the rest of it,
+// so that scanning can resume after an error. A string, hex, or ascii85
+// literal is skipped through its closing delimiter, and a comment through the
+// end of its line. Any other token is skipped up to the next whitespace or
+// delimiter.
+func (s *Scanner) resync() {
+	text := s.text.String()
+	s.text.Reset()
+	switch {
+	case strings.HasPrefix(text, "("):
+		s.skipString(text)
+		return
+	case strings.HasPrefix(text, "<~"):
+		switch rest := text[2:]; {
+		case strings.HasSuffix(rest, "~"):
+			// The closing quote was cut short; discard its ">" if present.
+			if b, err := s.byte(); err == nil && b != '>' {
+				s.unget()
+			}
+		case !strings.Contains(rest, "~"):
+			s.skipPast("~>")
+		}
+		return
+	case strings.HasPrefix(text, "<") && !strings.HasPrefix(text, "<<"):
+		if len(text) == 1 || !strings.HasSuffix(text, ">") {
+			s.skipPast(">")
+		}
+		return
+	case strings.HasPrefix(text, "%"):
+		if last := text[len(text)-1]; len(text) == 1 || (last != '\n' && last != '\f' && last != '\r') {
+			s.skipLine()
+		}
+		return
+	}
+	for {
+		b, err := s.byte()
+		if err != nil || isSpace(b) {
+			return
+		} else if isSpecial(b) {
+			s.unget()
+			return
+		}
+	}
+}
+
+// skipString discards input through the end of a string literal whose
+// partial text is given, counting nested parentheses.
+func (s *Scanner) skipString(text string) {
+	depth, esc := 0, false
+	step := func(b byte) {
+		if b == '\\' {
+			esc = !esc
+		} else if esc {
+			esc = false
+		} else if b == '(' {
+			depth++
+		} else if b == ')' {
+			depth--
+		}
+	}
+	for i := 0; i < len(text); i++ {
+		step(text[i])
+	}
+	for depth > 0 {
+		b, err := s.byte()
+		if err != nil {
+			return
+		}
+		step(b)
+	}
+}
+
+// skipPast discards input through the next occurrence of close.
+func (s *Scanner) skipPast(close string) {
+	for i := 0; i < len(close); {
+		b, err := s.byte()
+		if err != nil {
+			return
+		} else if b == close[i] {
+			i++
+		} else if b == close[0] {
+			i = 1
+		} else {
+			i = 0
+		}
+	}
+}
+
+// skipLine discards input through the next line terminator.
+func (s *Scanner) skipLine() {
+	for {
+		b, err := s.byte()
+		if err != nil || b == '\n' || b == '\f' {
+			return
+		} else if b == '\r' {
+			if c, err := s.byte(); err == nil && c != '\n' {
+				s.unget()
+			}
+			return
+		}
 	}
 }
 
@@ -239,32 +697,61 @@ func (s *Scanner) seterr(err error) error {
 	return err
 }
 
+// failf records and returns a *ScanError for the current token.
+func (s *Scanner) failf(msg string, args ...any) error {
+	return s.seterr(&ScanError{Pos: s.pos, Message: fmt.Sprintf(msg, args...)})
+}
+
 func (s *Scanner) byte() (byte, error) {
 	b, err := s.input.ReadByte()
 	if err == nil {
-		s.end++
+		s.read++
 	}
 	return b, err
 }
 
+// put adds b to the text of the current token, and reports an error if doing
+// so exceeds the maximum token length. The byte is added even if an error is
+// reported, so that resync can tell where the malformed token ends.
+func (s *Scanner) put(b byte) error {
+	s.text.WriteByte(b)
+	if s.MaxTokenBytes > 0 && s.text.Len() > s.MaxTokenBytes {
+		return s.failf("token exceeds maximum size of %d bytes", s.MaxTokenBytes)
+	}
+	return nil
+}
+
 func (s *Scanner) unget() {
 	s.input.UnreadByte()
-	s.end--
+	s.read--
 }
 
 func (s *Scanner) scanComment() error {
 	for {
 		b, err := s.byte()
-		if err == io.EOF {
-			s.end++
-		} else if err != nil {
+		if err == nil {
+			if err := s.put(b); err != nil {
+				return err
+			}
+		} else if err != io.EOF {
 			return s.seterr(err)
-		} else {
-			s.text.WriteByte(b)
 		}
 		if err == io.EOF || b == '\n' || b == '\f' {
 			s.token = Comment
 			return nil
+		} else if b == '\r' {
+			// A bare CR ends a comment; consume the LF of a CRLF pair.
+			if c, err := s.byte(); err == nil && c != '\n' {
+				s.unget()
+			} else if err == nil {
+				if err := s.put(c); err != nil {
+					return err
+				}
+			} else if err != io.EOF {
+				return s.seterr(err)
+			}
+			s.token = Comment
+			return nil
 		}
 	}
 }
@@ -275,7 +762,7 @@ func (s *Scanner) scanString() error {
 	for {
 		b, err := s.byte()
 		if err == io.EOF {
-			return s.seterr(errors.New("unterminated string"))
+			return s.failf("unterminated string")
 		} else if err != nil {
 			return s.seterr(err)
 		}
@@ -288,7 +775,9 @@ func (s *Scanner) scanString() error {
 		} else if b == ')' {
 			depth--
 		}
-		s.text.WriteByte(b)
+		if err := s.put(b); err != nil {
+			return err
+		}
 		if b == ')' && depth == 0 {
 			s.token = LitString
 			return nil
@@ -302,47 +791,65 @@ func (s *Scanner) scanHex() error {
 	for {
 		b, err := s.byte()
 		if err == io.EOF {
-			return s.seterr(errors.New("unterminated hex string"))
+			return s.failf("unterminated hex string")
 		} else if err != nil {
 			return s.seterr(err)
 		}
-		s.text.WriteByte(b)
+		if err := s.put(b); err != nil {
+			return err
+		}
 		if b == '>' {
 			s.token = HexString
 			return nil
 		} else if !isHex(b) && !isSpace(b) {
-			return s.seterr(fmt.Errorf("invalid hex %c", b))
+			return s.failf("%s", badHexMessage(b, s.read-1))
 		}
 	}
 }
 
+// badHexMessage returns an error message for an invalid byte b at the given
+// offset in a hex string. The byte is quoted so that control characters and
+// non-ASCII bytes are visible.
+func badHexMessage(b byte, offset int) string {
+	return fmt.Sprintf("invalid hex character %s (0x%02x) in hex string at offset %d",
+		strconv.QuoteRune(rune(b)), b, offset)
+}
+
 // scanA85 reads an ascii85 encoded string literal, assuming the leading quote
 // has already been buffered.
 func (s *Scanner) scanA85() error {
 	for {
 		b, err := s.byte()
 		if err == io.EOF {
-			return s.seterr(errors.New("unterminated ascii85 string"))
+			return s.failf("unterminated ascii85 string")
 		} else if err != nil {
 			return s.seterr(err)
 		}
-		s.text.WriteByte(b)
+		if err := s.put(b); err != nil {
+			return err
+		}
 		if b == '~' {
 			c, err := s.byte()
 			if err != nil || c != '>' {
-				return s.seterr(errors.New("invalid closing ascii85 quote"))
+				if err == nil {
+					s.text.WriteByte(c) // so resync knows the quote was read
+				}
+				return s.failf("invalid closing ascii85 quote")
+			}
+			if err := s.put('>'); err != nil {
+				return err
 			}
-			s.text.WriteByte('>')
 			s.token = A85String
 			return nil
 		} else if !isA85(b) && !isSpace(b) {
-			return s.seterr(fmt.Errorf("invalid ascii85 %c", b))
+			return s.failf("invalid ascii85 %c", b)
 		}
 	}
 }
 
 // scanNamelike reads and classifies a name or number token, whose first byte
-// is already buffered.
+// is already buffered. If first != 0, it is the first byte, and a second copy
+// of it immediately following is included in the token ("//", "<<", ">>").
 func (s *Scanner) scanNamelike(first byte) error {
 	// Ref: "Any token that consists entirely of regular characters and cannot
 	// be interpreted as a number is treated as a name object. All characters
@@ -352,7 +859,6 @@ func (s *Scanner) scanNamelike(first byte) error {
 	for {
 		b, err := s.byte()
 		if err == io.EOF {
-			s.end++
 			break
 		} else if err != nil {
 			return s.seterr(err)
@@ -365,7 +871,9 @@ func (s *Scanner) scanNamelike(first byte) error {
 			ok := b == first
 			first = 0
 			if ok {
-				s.text.WriteByte(b)
+				if err := s.put(b); err != nil {
+					return err
+				}
 				if b != '/' {
 					break // i.e., << or >>
 				}
@@ -378,7 +886,9 @@ func (s *Scanner) scanNamelike(first byte) error {
 			s.unget()
 			break
 		}
-		s.text.WriteByte(b)
+		if err := s.put(b); err != nil {
+			return err
+		}
 	}
 
 	// Upon reaching this point we have a name or a number in the buffer, but we
@@ -437,6 +947,35 @@ func decodeLiteral(s string) string {
 	return buf.String()
 }
 
+// removeContinuations removes escaped line breaks from the text of a string
+// literal, leaving all other escapes intact.
+func removeContinuations(s string) string {
+	if !strings.Contains(s, "\\\n") && !strings.Contains(s, "\\\r") {
+		return s
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, len(s)))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			buf.WriteByte(s[i])
+			continue
+		}
+		i++ // skip the backslash
+		switch s[i] {
+		case '\n':
+			// LF to be folded out
+		case '\r':
+			// CR or CRLF pair, to be folded out
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+		default:
+			buf.WriteByte('\\')
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String()
+}
+
 func decodeHex(s string) string {
 	var buf []byte
 
@@ -459,7 +998,8 @@ func decodeHex(s string) string {
 }
 
 func decodeA85(s string) string {
-	buf := make([]byte, len(s))
+	// Each "z" in the input expands to 4 bytes of output.
+	buf := make([]byte, 4*len(s))
 	nw, _, _ := ascii85.Decode(buf, []byte(s), true) // flush
 	return string(buf[:nw])
 }
@@ -488,7 +1028,9 @@ func hexVal(b byte) byte {
 
 func isOctal(b byte) bool { return b >= '0' && b <= '7' }
 
-func isA85(b byte) bool { return b >= '!' && b <= 'u' }
+// isA85 reports whether b may appear in an ascii85 literal. In addition to the
+// base-85 digits, "z" abbreviates a group of four zero bytes.
+func isA85(b byte) bool { return b >= '!' && b <= 'u' || b == 'z' }
 
 func isSpecial(b byte) bool {
 	switch b {
@@ -498,8 +1040,56 @@ func isSpecial(b byte) bool {
 	return false
 }
 
+// level2Names is a set of operator names that are not available in
+// PostScript language level 1.
+var level2Names = map[string]bool{
+	"<<": true, ">>": true,
+
+	// Color spaces and patterns.
+	"colorimage": true, "currentcolor": true, "currentcolorspace": true,
+	"makepattern": true, "setcolor": true, "setcolorspace": true,
+	"setpattern": true, "setcolorrendering": true, "currentcolorrendering": true,
+	"setoverprint": true, "currentoverprint": true,
+
+	// Graphics state and painting.
+	"currentgstate": true, "gstate": true, "setgstate": true,
+	"rectclip": true, "rectfill": true, "rectstroke": true,
+	"setstrokeadjust": true, "currentstrokeadjust": true,
+	"execform": true,
+
+	// Text.
+	"cshow": true, "glyphshow": true, "selectfont": true, "rootfont": true,
+	"xshow": true, "xyshow": true, "yshow": true,
+
+	// User paths.
+	"uappend": true, "ucache": true, "ueofill": true, "ufill": true,
+	"uinfill": true, "uinstroke": true, "upath": true, "ustroke": true,
+	"ustrokepath": true, "setucacheparams": true,
+
+	// Resources, memory, and devices.
+	"currentglobal": true, "globaldict": true, "setglobal": true,
+	"defineresource": true, "findresource": true, "resourceforall": true,
+	"resourcestatus": true, "undefineresource": true,
+	"currentpagedevice": true, "setpagedevice": true,
+	"setsystemparams": true, "currentsystemparams": true,
+	"setuserparams": true, "currentuserparams": true,
+	"setdevparams": true, "currentdevparams": true,
+
+	// Miscellaneous.
+	"filter": true, "languagelevel": true, "packedarray": true,
+	"printobject": true, "writeobject": true, "setobjectformat": true,
+	"startjob": true,
+}
+
 // A mapping of pairs of token types that need whitespace to separate them.
 // Given types x and y, spaces[x][y] == true if x followed by y requires space.
+//
+// Only a name or number followed by a name or number needs space. All other
+// pairs are separated by a delimiter: strings, braces, and the slash of a
+// quoted name are self-delimiting, and a comment includes its terminator.
+//
+// One case the table cannot capture: the empty quoted name "/" followed by a
+// quoted or immediate name will merge with it, so "/" then "/a" becomes "//a".
 var spaces = [numTypes][numTypes]bool{
 	Decimal:       {Decimal: true, Radix: true, Real: true, Name: true},
 	Radix:         {Decimal: true, Radix: true, Real: true, Name: true},
@@ -512,3 +1102,60 @@ var spaces = [numTypes][numTypes]bool{
 // NeedSpaceBetween reports whether spaces are required between a token of type
 // prev and a token of type next to preserve lexical structure.
 func NeedSpaceBetween(prev, next Type) bool { return spaces[prev][next] }
+
+// A Minifier removes comments and unnecessary whitespace from PostScript
+// source text. The zero value is ready for use.
+type Minifier struct {
+	// KeepLeadingComments, if true, preserves the comments that precede the
+	// first non-comment token, such as the "%!PS-Adobe" magic line.
+	KeepLeadingComments bool
+}
+
+// Minify copies the PostScript source text from r to w, removing comments and
+// any whitespace not needed to separate tokens. It returns nil if all of r was
+// read successfully, or otherwise the first scan or write error.
+func (m Minifier) Minify(r io.Reader, w io.Writer) error {
+	s := New(r)
+	var last Type
+	var lastText string
+	pastHead := false
+	for s.Next() == nil {
+		cur := s.Type()
+		if cur == Comment {
+			if pastHead || !m.KeepLeadingComments {
+				continue
+			}
+		} else {
+			pastHead = true
+		}
+
+		// The empty name "/" merges with a following quoted or immediate name,
+		// which the spaces table does not capture.
+		space := NeedSpaceBetween(last, cur) ||
+			(lastText == "/" && (cur == QuotedName || cur == ImmediateName))
+		if space {
+			if _, err := io.WriteString(w, " "); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, s.Text()); err != nil {
+			return err
+		}
+		last, lastText = cur, s.Text()
+	}
+	if s.Err() == io.EOF {
+		return nil
+	}
+	return s.Err()
+}
+
+// Minify copies the PostScript source text from r to w, removing comments and
+// unnecessary whitespace. It is shorthand for Minifier{}.Minify(r, w).
+func Minify(r io.Reader, w io.Writer) error { return Minifier{}.Minify(r, w) }
+
+// MinifyString returns the result of applying Minify to input.
+func MinifyString(input string) (string, error) {
+	var buf strings.Builder
+	err := Minify(strings.NewReader(input), &buf)
+	return buf.String(), err
+}
@@ -1,7 +1,9 @@
 package scanner
 
 import (
+	"bufio"
 	"io"
+	"os"
 	"strings"
 	"testing"
 )
@@ -20,57 +22,6 @@ func scan(t *testing.T, input string, f func(i int, s *Scanner)) {
 	}
 }
 
-func TestRawTokens(t *testing.T) {
-	tests := []struct {
-		input string
-		want  []string
-	}{
-		// Empty or all-whitespace inputs should produce no tokens.
-		{"", nil},
-		{"   ", nil},
-		{"\t \t", nil},
-
-		// Comments should include their terminator.
-		{"% hello\n%% goodbye", []string{"% hello\n", "%% goodbye"}},
-
-		// Somewhat unusually, comments can end with form-feed.
-		{"% hello\f % world\n ", []string{"% hello\f", "% world\n"}},
-
-		// Various name-shaped things.
-		{"a /b //c $d ", []string{"a", "/b", "//c", "$d"}},
-		{"-3\n2.5e9\n 2#1101", []string{"-3", "2.5e9", "2#1101"}},
-
-		// Slashes should terminate name processing except at the start.
-		{"eat/your//veggies", []string{"eat", "/your", "//veggies"}},
-
-		// Self-delimiting names should delimit themselves.
-		{"{a<<b>>c[d]}", []string{"{", "a", "<<", "b", ">>", "c", "[", "d", "]", "}"}},
-
-		// String literals preserve whitespace inside them.
-		{"(a\nb\nc d)", []string{"(a\nb\nc d)"}},
-
-		// String literals respect balanced nested quotations, and unbalanced
-		// nested quotations can be quoted. Note that at this point we have not
-		// done any decoding so all the escapes are still there.
-		{" (a (b c)\n d)\n", []string{"(a (b c)\n d)"}},
-		{`(abc\(def)`, []string{`(abc\(def)`}},
-		{`(\)\\\))`, []string{`(\)\\\))`}},
-
-		// Hex and A85 literals.
-		{"<66 6f 6f><~  AoDS  ~>", []string{"<66 6f 6f>", "<~  AoDS  ~>"}},
-	}
-	for _, test := range tests {
-		scan(t, test.input, func(i int, s *Scanner) {
-			got := s.Text()
-			if i >= len(test.want) {
-				t.Errorf("Extra token %d: %#q", i, got)
-			} else if got != test.want[i] {
-				t.Errorf("Token %d: got %#q, want %#q", i, got, test.want[i])
-			}
-		})
-	}
-}
-
 func TestTokenTypes(t *testing.T) {
 	tests := []struct {
 		input string
@@ -81,6 +32,9 @@ func TestTokenTypes(t *testing.T) {
 		{"//", []Type{ImmediateName}},
 		{"///", []Type{ImmediateName, QuotedName}},
 		{" % ok\n all is /well", []Type{Comment, Name, Name, QuotedName}},
+		{"% comment\rnext", []Type{Comment, Name}},
+		{"% comment\r\nnext", []Type{Comment, Name}},
+		{"% comment\nnext", []Type{Comment, Name}},
 		{"//imm/o/lation", []Type{ImmediateName, QuotedName, QuotedName}},
 		{"-.002 123 -98 16#FFFE", []Type{Real, Decimal, Decimal, Radix}},
 		{"[alpha/bravo] % ok\n{charlie 1}", []Type{
@@ -106,52 +60,6 @@ func TestTokenTypes(t *testing.T) {
 	}
 }
 
-func TestString(t *testing.T) {
-	tests := []struct {
-		input string
-		want  []string
-	}{
-		// String literals.
-		{"(a (b) c)", []string{"a (b) c"}},
-		{"(a \\(b c)", []string{"a (b c"}},
-		{"  (a\n b c\\) def)", []string{"a\n b c) def"}},
-		{"(ab\\\ncd\\\ref\\\r\ngh)", []string{"abcdefgh"}},
-
-		// Hex literals.
-		{"<> <  > <50> <5>", []string{"", "", "P", "P"}},
-		{"<66 6f 6f>", []string{"foo"}},
-		{"<32 31 3>", []string{"210"}},
-
-		// A85 literals.
-		{"<~~> <~  ~> <~ AoDS ~>", []string{"", "", "foo"}},
-
-		// Names and punctuation.
-		{"alpha/bravo charlie //xray", []string{"alpha", "bravo", "charlie", "xray"}},
-		{"[full /plate (and) {packing}]<<steel>>", []string{
-			"[", "full", "plate", "and", "{", "packing", "}", "]", "<<", "steel", ">>",
-		}},
-
-		// Comments.
-		{"% foo\n% bar\f\n% baz\n ", []string{"foo", "bar", "baz"}},
-		{"%% I am the lizard king\n\n% I can do anything\f\n", []string{
-			"I am the lizard king", "I can do anything",
-		}},
-
-		// Numbers.
-		{"1.3 .0 2#1101 6.67e-11", []string{"1.3", ".0", "2#1101", "6.67e-11"}},
-	}
-	for _, test := range tests {
-		scan(t, test.input, func(i int, s *Scanner) {
-			got := s.String()
-			if i >= len(test.want) {
-				t.Errorf("Extra token %d: %v %#q", i, s.Type(), s.Text())
-			} else if got != test.want[i] {
-				t.Errorf("Token %d: got %v %#q, want %#q", i, s.Type(), got, test.want[i])
-			}
-		})
-	}
-}
-
 func TestTokenValues(t *testing.T) {
 	scan(t, "-2 -1 0 1 2", func(i int, s *Scanner) {
 		want := int64(i) - 2
@@ -175,6 +83,49 @@ func TestTokenValues(t *testing.T) {
 }
 
 func TestPositions(t *testing.T) {
+	type span struct{ pos, end int }
+	tests := []struct {
+		input string
+		want  []span
+	}{
+		// The first token starts at offset 0.
+		{"a", []span{{0, 1}}},
+
+		// Leading whitespace is not part of the token.
+		{"  \n\tabc ", []span{{4, 7}}},
+
+		// A multi-character token spans its whole text.
+		{"moveto lineto", []span{{0, 6}, {7, 13}}},
+
+		// String quotes are included in the span.
+		{" <66 6f 6f>", []span{{1, 11}}},
+		{"<~AoDS~> ", []span{{0, 8}}},
+		{"(a (b) c)", []span{{0, 9}}},
+
+		// A comment includes its terminator.
+		{"% hi\nx", []span{{0, 5}, {5, 6}}},
+
+		// Tokens immediately followed by another.
+		{"a{b}", []span{{0, 1}, {1, 2}, {2, 3}, {3, 4}}},
+		{"/a(b)//c", []span{{0, 2}, {2, 5}, {5, 8}}},
+	}
+	for _, test := range tests {
+		scan(t, test.input, func(i int, s *Scanner) {
+			got := span{s.Pos(), s.End()}
+			if i >= len(test.want) {
+				t.Errorf("Extra token %d: %#q", i, s.Text())
+				return
+			} else if got != test.want[i] {
+				t.Errorf("Token %d: got span %v, want %v", i, got, test.want[i])
+			}
+			if text := test.input[got.pos:got.end]; text != s.Text() {
+				t.Errorf("Token %d: input span is %#q, text is %#q", i, text, s.Text())
+			}
+		})
+	}
+}
+
+func TestProgramPositions(t *testing.T) {
 	// To verify that positions are correct, grab the original text of each
 	// token and compare the corresponding range of the input string to the
 	// token's putative text.
@@ -204,30 +155,912 @@ moveto (Hello, world\n) show
 	})
 }
 
-func TestScanErrors(t *testing.T) {
+func TestCounters(t *testing.T) {
+	tests := []struct {
+		input  string
+		tokens int
+	}{
+		{"", 0},
+		{"   ", 0},
+		{"abc", 1},
+		{"/a/b{1 2}", 6},
+		{"% comment", 1},
+		{"  (a b c) <66 6f> <~AoDS~>\n", 3},
+		{"1 2 3 add add\n", 5},
+	}
+	for _, test := range tests {
+		s := New(strings.NewReader(test.input))
+		for s.Next() == nil {
+		}
+		if err := s.Err(); err != io.EOF {
+			t.Errorf("Scanning %#q: got %v, want EOF", test.input, err)
+		}
+		if got := s.Count(); got != test.tokens {
+			t.Errorf("Scanning %#q: Count() = %d, want %d", test.input, got, test.tokens)
+		}
+		if got := s.BytesRead(); got != len(test.input) {
+			t.Errorf("Scanning %#q: BytesRead() = %d, want %d", test.input, got, len(test.input))
+		}
+
+		s.Reset(strings.NewReader(test.input))
+		if c, n := s.Count(), s.BytesRead(); c != 0 || n != 0 {
+			t.Errorf("After Reset: Count() = %d, BytesRead() = %d, want 0, 0", c, n)
+		}
+	}
+}
+
+func TestErrAt(t *testing.T) {
+	s := New(strings.NewReader("a b c"))
+	for s.Next() == nil {
+	}
+	if err := s.ErrAt(); err != nil {
+		t.Errorf("At EOF: ErrAt() = %v, want nil", err)
+	}
+
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{"(unterminated", 0},
+		{"abc  (unterminated", 5},
+		{"1 2 <~ AoDS", 4},
+		{"x\n<66 zz>", 2},
+		{"{ < ", 2},
+	}
+	for _, test := range tests {
+		s := New(strings.NewReader(test.input))
+		for s.Next() == nil {
+		}
+		se := s.ErrAt()
+		if se == nil {
+			t.Errorf("Scanning %#q: ErrAt() = nil, want error (Err = %v)", test.input, s.Err())
+			continue
+		}
+		if se.Pos != test.pos {
+			t.Errorf("Scanning %#q: error at %d, want %d", test.input, se.Pos, test.pos)
+		}
+		if se != s.Err() {
+			t.Errorf("Scanning %#q: ErrAt() = %v, Err() = %v", test.input, se, s.Err())
+		}
+	}
+}
+
+func TestMagicLine(t *testing.T) {
+	tests := []struct {
+		input        string
+		magic        bool
+		major, minor int
+		ok           bool
+	}{
+		{"%!PS-Adobe-3.0\n", true, 3, 0, true},
+		{"%!PS-Adobe-2.0 EPSF-2.0\n", true, 2, 0, true},
+		{"%!PS-Adobe-3.1", true, 3, 1, true},
+		{"%!PS\n", true, 0, 0, false},
+		{"%!\n", true, 0, 0, false},
+		{"% !PS-Adobe-3.0\n", false, 0, 0, false},
+		{"%% ordinary comment\n", false, 0, 0, false},
+		{"(%!PS-Adobe-3.0)", false, 0, 0, false},
+	}
+	for _, test := range tests {
+		scan(t, test.input, func(i int, s *Scanner) {
+			if got := s.IsMagicLine(); got != test.magic {
+				t.Errorf("IsMagicLine(%#q): got %v, want %v", s.Text(), got, test.magic)
+			}
+			major, minor, ok := s.PSVersion()
+			if major != test.major || minor != test.minor || ok != test.ok {
+				t.Errorf("PSVersion(%#q): got %d, %d, %v; want %d, %d, %v",
+					s.Text(), major, minor, ok, test.major, test.minor, test.ok)
+			}
+		})
+	}
+}
+
+func TestScanTestdataFile(t *testing.T) {
+	f, err := os.Open("testdata/corpus.ps")
+	if err != nil {
+		t.Fatalf("Opening test input: %v", err)
+	}
+	defer f.Close()
+
+	var got [numTypes]int
+	s := New(f)
+	for s.Next() == nil {
+		got[s.Type()]++
+	}
+	if err := s.Err(); err != io.EOF {
+		t.Fatalf("Scanning test input: got %v, want EOF", err)
+	}
+
+	want := [numTypes]int{
+		Comment:       194,
+		LitString:     150,
+		HexString:     150,
+		A85String:     150,
+		Decimal:       2118,
+		Radix:         155,
+		Real:          600,
+		Name:          3162,
+		QuotedName:    163,
+		ImmediateName: 150,
+		Left:          306,
+		Right:         306,
+	}
+	if got != want {
+		t.Errorf("Token counts by type:\n got %v\nwant %v", got, want)
+	}
+	if n := s.Count(); n != 7604 {
+		t.Errorf("Total tokens: got %d, want %d", n, 7604)
+	}
+}
+
+func TestScanTestdataRoundTrip(t *testing.T) {
+	input, err := os.ReadFile("testdata/corpus.ps")
+	if err != nil {
+		t.Fatalf("Reading test input: %v", err)
+	}
+
+	// Emit all the non-comment tokens with only the necessary spacing.
+	var buf strings.Builder
+	var want []Type
+	var last Type
+	scan(t, string(input), func(_ int, s *Scanner) {
+		cur := s.Type()
+		if cur == Comment {
+			return
+		}
+		if NeedSpaceBetween(last, cur) {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(s.Text())
+		want = append(want, cur)
+		last = cur
+	})
+	t.Logf("Minified %d bytes to %d", len(input), buf.Len())
+
+	// Scanning the output should give back the same sequence of types.
+	var got []Type
+	scan(t, buf.String(), func(_ int, s *Scanner) {
+		got = append(got, s.Type())
+	})
+	if len(got) != len(want) {
+		t.Errorf("Got %d tokens, want %d", len(got), len(want))
+	}
+	for i := 0; i < len(got) && i < len(want); i++ {
+		if got[i] != want[i] {
+			t.Errorf("Token %d: got %v, want %v", i, got[i], want[i])
+			break
+		}
+	}
+}
+
+func TestScanFunc(t *testing.T) {
+	t.Run("Complete", func(t *testing.T) {
+		var got []string
+		s := New(strings.NewReader("/a { 1 add } def"))
+		if err := s.ScanFunc(func(s *Scanner) error {
+			got = append(got, s.Text())
+			return nil
+		}); err != nil {
+			t.Errorf("ScanFunc: unexpected error: %v", err)
+		}
+		want := []string{"/a", "{", "1", "add", "}", "def"}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("ScanFunc tokens: got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("EarlyStop", func(t *testing.T) {
+		var got []Type
+		s := New(strings.NewReader("1 2 (stop) 3 4"))
+		err := s.ScanFunc(func(s *Scanner) error {
+			got = append(got, s.Type())
+			if s.Type() == LitString {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		})
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("ScanFunc: got error %v, want %v", err, io.ErrUnexpectedEOF)
+		}
+		if len(got) != 3 || got[2] != LitString {
+			t.Errorf("ScanFunc: got types %v, want 3 ending in LitString", got)
+		}
+	})
+
+	t.Run("ScanError", func(t *testing.T) {
+		var n int
+		s := New(strings.NewReader("a b (unterminated"))
+		err := s.ScanFunc(func(*Scanner) error { n++; return nil })
+		if _, ok := err.(*ScanError); !ok {
+			t.Errorf("ScanFunc: got error %v, want *ScanError", err)
+		}
+		if n != 2 {
+			t.Errorf("ScanFunc: callback ran %d times, want 2", n)
+		}
+	})
+}
+
+func TestMaxTokenBytes(t *testing.T) {
 	tests := []string{
-		// Unterminated string literals.
-		`(unterminated string`,
-		`(`,
-		`<ac 9e 30`,
-		`<`,
-		`<~ apple pie `,
-		`<~`,
-
-		// Invalid contents.
-		`< BOGUS HEX>`,
-		`<~ xxx is not legal A85 xxx ~>`,
-		`<~ all good so far oops ~ ~>`,
+		"% a comment\n",
+		"(a string)",
+		"<66 6f 6f>",
+		"<~AoDS~>",
+		"some-name",
+		"/quoted",
+		"12345",
+		"<<",
+	}
+	for _, input := range tests {
+		for _, limit := range []int{0, len(input), len(input) - 1} {
+			s := New(strings.NewReader(input))
+			s.MaxTokenBytes = limit
+
+			err := s.Next()
+			if limit == 0 || limit >= len(input) {
+				if err != nil {
+					t.Errorf("Scanning %#q with limit %d: unexpected error: %v", input, limit, err)
+				} else if s.Text() != input {
+					t.Errorf("Scanning %#q with limit %d: got %#q", input, limit, s.Text())
+				}
+			} else if se, ok := err.(*ScanError); !ok {
+				t.Errorf("Scanning %#q with limit %d: got %v, want *ScanError", input, limit, err)
+			} else {
+				t.Logf("Scanning %#q with limit %d: got %v [OK]", input, limit, se)
+			}
+		}
+	}
+}
+
+// samples gives some representative texts for each token type.
+var samples = [numTypes][]string{
+	Comment:       {"% c\n", "%%DSC: x\n"},
+	LitString:     {"(s)", "()", "(a (b) c)"},
+	HexString:     {"<66>", "<>"},
+	A85String:     {"<~AoDS~>", "<~~>"},
+	Decimal:       {"12", "-3", "+4"},
+	Radix:         {"16#FF", "2#101"},
+	Real:          {"1.5", "-.5", "1.", "1e5"},
+	Name:          {"nm", "[", "]", "<<", ">>", "$x"},
+	QuotedName:    {"/qn", "/1", "/-x"},
+	ImmediateName: {"//in", "//2"},
+	Left:          {"{"},
+	Right:         {"}"},
+}
+
+func TestSpacingMatrix(t *testing.T) {
+	// For each pair of types that do not need a space between them, check that
+	// concatenating their texts and re-scanning preserves both tokens.
+	// The samples deliberately omit the empty names "/" and "//", which may
+	// merge with a following name (see the comment on the spaces table).
+	for prev := Type(1); prev < numTypes; prev++ {
+		for next := Type(1); next < numTypes; next++ {
+			if NeedSpaceBetween(prev, next) {
+				continue
+			}
+			for _, a := range samples[prev] {
+				for _, b := range samples[next] {
+					input := a + b
+					s := New(strings.NewReader(input))
+					var got []Type
+					var text []string
+					for s.Next() == nil {
+						got = append(got, s.Type())
+						text = append(text, s.Text())
+					}
+					if s.Err() != io.EOF {
+						t.Errorf("Scanning %#q: unexpected error: %v", input, s.Err())
+					} else if len(got) != 2 || got[0] != prev || got[1] != next || text[0] != a || text[1] != b {
+						t.Errorf("Scanning %#q: got %v %q, want [%v %v]", input, got, text, prev, next)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestTokenIndex(t *testing.T) {
+	const input = "/a { 1 (two) <~AoDS~> } def % ok"
+	s := New(strings.NewReader(input))
+	if got := s.TokenIndex(); got != 0 {
+		t.Errorf("Before Next: TokenIndex() = %d, want 0", got)
+	}
+	var n int
+	for ; s.Next() == nil; n++ {
+		if got := s.TokenIndex(); got != n {
+			t.Errorf("Token %d %#q: TokenIndex() = %d", n, s.Text(), got)
+		}
+	}
+	if n != 8 {
+		t.Errorf("Scanned %d tokens, want 8", n)
+	}
+	if got := s.TokenIndex(); got != n-1 {
+		t.Errorf("At EOF: TokenIndex() = %d, want %d", got, n-1)
+	}
+
+	s.Reset(strings.NewReader(input))
+	if got := s.TokenIndex(); got != 0 {
+		t.Errorf("After Reset: TokenIndex() = %d, want 0", got)
+	}
+}
+
+func TestNormalizeTokens(t *testing.T) {
+	tests := []struct {
+		input     string
+		raw, norm string
+	}{
+		{"16#ff", "16#ff", "16#FF"},
+		{"36#Zz9", "36#Zz9", "36#ZZ9"},
+		{"(hel\\\nlo)", "(hel\\\nlo)", "(hello)"},
+		{"(a\\\r\nb\\\rc)", "(a\\\r\nb\\\rc)", "(abc)"},
+		{`(a\\b\)c)`, `(a\\b\)c)`, `(a\\b\)c)`},
+		{"(line\nbreak)", "(line\nbreak)", "(line\nbreak)"},
+		{"< 66 6f\n6f >", "< 66 6f\n6f >", "<666f6f>"},
+		{"<~ AoDS ~>", "<~ AoDS ~>", "<~ AoDS ~>"},
+		{"/Name", "/Name", "/Name"},
+		{"-1.5e3", "-1.5e3", "-1.5e3"},
+	}
+	for _, test := range tests {
+		raw := New(strings.NewReader(test.input))
+		norm := New(strings.NewReader(test.input))
+		norm.NormalizeTokens = true
+		if err := raw.Next(); err != nil {
+			t.Fatalf("Next %#q: unexpected error: %v", test.input, err)
+		}
+		if err := norm.Next(); err != nil {
+			t.Fatalf("Next %#q: unexpected error: %v", test.input, err)
+		}
+		if got := raw.Text(); got != test.raw {
+			t.Errorf("Raw %#q: got %#q, want %#q", test.input, got, test.raw)
+		}
+		if got := norm.Text(); got != test.norm {
+			t.Errorf("Normalized %#q: got %#q, want %#q", test.input, got, test.norm)
+		}
+		if raw.Type() == Radix {
+			r, _ := raw.Int64()
+			n, _ := norm.Int64()
+			if r != n {
+				t.Errorf("Int64 %#q: raw %d, normalized %d", test.input, r, n)
+			}
+		} else if r, n := raw.String(), norm.String(); r != n {
+			t.Errorf("String %#q: raw %#q, normalized %#q", test.input, r, n)
+		}
+		if r, n := [2]int{raw.Pos(), raw.End()}, [2]int{norm.Pos(), norm.End()}; r != n {
+			t.Errorf("Span %#q: raw %v, normalized %v", test.input, r, n)
+		}
+	}
+}
+
+func TestNewFromBufioScanner(t *testing.T) {
+	const input = "/msg (hello,\nworld) def\r\n% comment\nmsg = <66\n6f 6f>"
+	s := NewFromBufioScanner(bufio.NewScanner(strings.NewReader(input)))
+
+	want := []string{"msg", "hello,\nworld", "def", "comment", "msg", "=", "foo"}
+	var got []string
+	for s.Next() == nil {
+		got = append(got, s.String())
+	}
+	if err := s.Err(); err != io.EOF {
+		t.Errorf("After scanning: got %v, want EOF", err)
+	}
+	if len(got) != len(want) {
+		t.Errorf("Got %d tokens %q, want %d %q", len(got), got, len(want), want)
+	}
+	for i := 0; i < len(got) && i < len(want); i++ {
+		if got[i] != want[i] {
+			t.Errorf("Token %d: got %#q, want %#q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestConsumeWhitespace(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int    // whitespace bytes consumed after the first token
+		next  string // the text of the following token, if any
+	}{
+		{"a", 0, ""},
+		{"a   ", 3, ""},
+		{"a{b", 0, "{"},
+		{"a \t\r\n\f\x00 b", 7, "b"},
+		{"(x)  % c\n", 2, "% c\n"},
+		{"% a\n  1", 2, "1"},
+	}
+	for _, test := range tests {
+		s := New(strings.NewReader(test.input))
+		if err := s.Next(); err != nil {
+			t.Fatalf("Next %#q: unexpected error: %v", test.input, err)
+		}
+		text, pos, end := s.Text(), s.Pos(), s.End()
+		n, err := s.ConsumeWhitespace()
+		if err != nil {
+			t.Errorf("ConsumeWhitespace %#q: unexpected error: %v", test.input, err)
+		}
+		if s.Text() != text || s.Pos() != pos || s.End() != end {
+			t.Errorf("ConsumeWhitespace %#q: token changed to %#q at %d..%d, want %#q at %d..%d",
+				test.input, s.Text(), s.Pos(), s.End(), text, pos, end)
+		}
+		if n != test.want {
+			t.Errorf("ConsumeWhitespace %#q: got %d, want %d", test.input, n, test.want)
+		}
+		if got := s.BytesRead(); got != end+n {
+			t.Errorf("ConsumeWhitespace %#q: BytesRead() = %d, want %d", test.input, got, end+n)
+		}
+
+		if err := s.Next(); test.next == "" {
+			if err != io.EOF {
+				t.Errorf("Next %#q: got %v, want EOF", test.input, err)
+			}
+		} else if err != nil {
+			t.Errorf("Next %#q: unexpected error: %v", test.input, err)
+		} else if s.Text() != test.next || s.Pos() != end+n {
+			t.Errorf("Next %#q: got %#q at %d, want %#q at %d", test.input, s.Text(), s.Pos(), test.next, end+n)
+		}
+	}
+}
+
+func TestMinify(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"", ""},
+		{"% just a comment\n", ""},
+		{"%!PS\n/in { 72 mul } def % inches\n1 in 2 in moveto\n",
+			"/in{72 mul}def 1 in 2 in moveto"},
+		{"[ 1 2 3 ] { pop } forall", "[ 1 2 3 ]{pop}forall"},
+		{"559 moveto 667 aab", "559 moveto 667 aab"},
+		{"/ /a / //b /c /d", "/ /a/ //b/c/d"},
+		{"( keep  spaces ) < 66 6f > /a /b //c 1.5 16#FF", "( keep  spaces )< 66 6f >/a/b//c 1.5 16#FF"},
 	}
 	for _, test := range tests {
-		s := New(strings.NewReader(test))
-		for i := 0; s.Next() == nil; i++ {
-			t.Logf("Token %d: %v %#q", i, s.Type(), s.Text())
+		got, err := MinifyString(test.input)
+		if err != nil {
+			t.Errorf("MinifyString(%#q): unexpected error: %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("MinifyString(%#q):\n got %#q\nwant %#q", test.input, got, test.want)
+		}
+	}
+
+	if got, err := MinifyString("ok (unterminated"); err == nil {
+		t.Errorf("MinifyString: got %#q, wanted error", got)
+	}
+}
+
+func TestMinifyRoundTrip(t *testing.T) {
+	input, err := os.ReadFile("testdata/corpus.ps")
+	if err != nil {
+		t.Fatalf("Reading test input: %v", err)
+	}
+	out, err := MinifyString(string(input))
+	if err != nil {
+		t.Fatalf("MinifyString: unexpected error: %v", err)
+	}
+
+	// The output should have the same non-comment tokens as the input.
+	var want []string
+	scan(t, string(input), func(_ int, s *Scanner) {
+		if s.Type() != Comment {
+			want = append(want, s.Text())
+		}
+	})
+	var got []string
+	scan(t, out, func(_ int, s *Scanner) { got = append(got, s.Text()) })
+	if strings.Join(got, "\x00") != strings.Join(want, "\x00") {
+		t.Errorf("Minified output has %d tokens, want %d matching the input", len(got), len(want))
+	}
+}
+
+func TestReadHexString(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		rest  string // the text of the following token, if any
+	}{
+		{"<>", "", ""},
+		{"<FF>", "\xff", ""},
+		{"<DEADBEEF>", "\xde\xad\xbe\xef", ""},
+		{"  <de ad\nbe\tef > next", "\xde\xad\xbe\xef", "next"},
+		{"<5>", "P", ""},
+		{"<66 6f 6f>(bar)", "foo", "(bar)"},
+	}
+	for _, test := range tests {
+		s := New(strings.NewReader(test.input))
+		got, err := s.ReadHexString()
+		if err != nil {
+			t.Errorf("ReadHexString %#q: unexpected error: %v", test.input, err)
+			continue
+		} else if string(got) != test.want {
+			t.Errorf("ReadHexString %#q: got %q, want %q", test.input, got, test.want)
+		}
+		if err := s.Next(); test.rest == "" {
+			if err != io.EOF {
+				t.Errorf("Next after %#q: got %v, want EOF", test.input, err)
+			}
+		} else if err != nil || s.Text() != test.rest {
+			t.Errorf("Next after %#q: got %#q, %v; want %#q", test.input, s.Text(), err, test.rest)
+		}
+	}
+
+	for _, bad := range []string{"", "<DEAD", "<", "<12 xy>", "(abc)"} {
+		s := New(strings.NewReader(bad))
+		got, err := s.ReadHexString()
+		if _, ok := err.(*ScanError); !ok {
+			t.Errorf("ReadHexString %#q: got %q, %v; want *ScanError", bad, got, err)
+		}
+	}
+
+	// The current token is not changed.
+	const input = "abc    <FF> next"
+	s := New(strings.NewReader(input))
+	if err := s.Next(); err != nil {
+		t.Fatalf("Next %#q: unexpected error: %v", input, err)
+	}
+	if _, err := s.ReadHexString(); err != nil {
+		t.Errorf("ReadHexString %#q: unexpected error: %v", input, err)
+	}
+	if s.Text() != "abc" || s.Pos() != 0 || s.End() != 3 {
+		t.Errorf("After ReadHexString: got %#q at %d..%d, want %#q at 0..3", s.Text(), s.Pos(), s.End(), "abc")
+	}
+	if got := s.BytesRead(); got != 11 {
+		t.Errorf("After ReadHexString: BytesRead() = %d, want 11", got)
+	}
+
+	// The token size limit applies to the text of the literal.
+	long := "<" + strings.Repeat("ab", 50) + ">"
+	for _, limit := range []int{0, 4, len(long) - 1, len(long)} {
+		s := New(strings.NewReader(long))
+		s.MaxTokenBytes = limit
+		got, err := s.ReadHexString()
+		if limit == 0 || limit >= len(long) {
+			if err != nil || len(got) != 50 {
+				t.Errorf("ReadHexString with limit %d: got %d bytes, %v; want 50, nil", limit, len(got), err)
+			}
+		} else if _, ok := err.(*ScanError); !ok {
+			t.Errorf("ReadHexString with limit %d: got %d bytes, %v; want *ScanError", limit, len(got), err)
+		}
+	}
+}
+
+func TestContinueOnError(t *testing.T) {
+	const input = "1 <zz> 2 <12 q4> 3 <~ wx~> 4 (unterminated"
+	s := New(strings.NewReader(input)).ContinueOnError()
+
+	var got []string
+	var nerr int
+	for {
+		err := s.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Logf("Error: %v", err)
+			nerr++
+			continue
+		}
+		got = append(got, s.Text())
+	}
+	if want := "1 2 3 4"; strings.Join(got, " ") != want {
+		t.Errorf("Tokens: got %q, want %q", got, want)
+	}
+
+	errs := s.Errors()
+	if len(errs) != 4 || nerr != len(errs) {
+		t.Errorf("Errors: got %d reported, %d recorded; want 4", nerr, len(errs))
+	}
+	wantPos := []int{2, 9, 19, 29}
+	for i, err := range errs {
+		se, ok := err.(*ScanError)
+		if !ok {
+			t.Errorf("Error %d: got %T, want *ScanError", i, err)
+		} else if i < len(wantPos) && se.Pos != wantPos[i] {
+			t.Errorf("Error %d: got offset %d, want %d", i, se.Pos, wantPos[i])
+		}
+	}
+
+	// Without recovery mode, no errors are recorded.
+	s = New(strings.NewReader(input))
+	for s.Next() == nil {
+	}
+	if errs := s.Errors(); len(errs) != 0 {
+		t.Errorf("Errors without recovery: got %v, want none", errs)
+	}
+}
+
+func TestResync(t *testing.T) {
+	tests := []struct {
+		input string
+		limit int
+		want  string
+	}{
+		// Whitespace inside a malformed literal does not end it.
+		{"<12 q4 56> next", 0, "next"},
+		{"<12 <34 56> next", 0, "next"},
+		{"<~ab wx yz~> next", 0, "next"},
+		{"<~ab~x next", 0, "next"},
+
+		// Tokens that exceed the limit are skipped through their ends.
+		{"(a (b) \\) c) next", 4, "next"},
+		{"(ab) ok", 3, "ok"},
+		{"<61 62 63> next", 4, "next"},
+		{"<616> next", 4, "next"},
+		{"<~AoDS ~> next", 4, "next"},
+		{"% a long comment\nnext", 4, "next"},
+		{"some-name next", 4, "next"},
+		{"/verylongname>> next", 4, ">> next"},
+		{"<~AoDS~>>> x", 7, ">> x"},
+		{"<~AoDS~>>> x", 6, ">> x"},
+	}
+	for _, test := range tests {
+		s := New(strings.NewReader(test.input)).ContinueOnError()
+		s.MaxTokenBytes = test.limit
+
+		var got []string
+		for {
+			err := s.Next()
+			if err == io.EOF {
+				break
+			} else if err == nil {
+				got = append(got, s.Text())
+			}
+		}
+		if g := strings.Join(got, " "); g != test.want {
+			t.Errorf("Scan %#q (limit %d): got tokens %q, want %q", test.input, test.limit, g, test.want)
+		}
+		if n := len(s.Errors()); n != 1 {
+			t.Errorf("Scan %#q (limit %d): got %d errors, want 1", test.input, test.limit, n)
+		}
+	}
+}
+
+func TestAll(t *testing.T) {
+	s := New(strings.NewReader("/a 1 def (x) b (oops"))
+
+	// Stop after the first three tokens.
+	var got []Token
+	s.All(func(tok Token, err error) bool {
+		if err != nil {
+			t.Fatalf("All: unexpected error: %v", err)
+		}
+		got = append(got, tok)
+		return len(got) < 3
+	})
+	want := []Token{
+		{Type: QuotedName, Text: "/a", Pos: 0, End: 2},
+		{Type: Decimal, Text: "1", Pos: 3, End: 4},
+		{Type: Name, Text: "def", Pos: 5, End: 8},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("All: got %d tokens, want %d", len(got), len(want))
+	}
+	for i, tok := range got {
+		if tok != want[i] {
+			t.Errorf("Token %d: got %+v, want %+v", i, tok, want[i])
 		}
-		if err := s.Err(); err == nil || err == io.EOF {
-			t.Errorf("Scanning %#q: got %v, wanted failure", test, err)
+	}
+
+	// A second call continues where the first stopped, and ends with the error.
+	var rest []string
+	var last error
+	s.All(func(tok Token, err error) bool {
+		if err != nil {
+			last = err
 		} else {
-			t.Logf("Scanning %#q: got %v [OK]", test, err)
+			rest = append(rest, tok.Text)
+		}
+		return true
+	})
+	if strings.Join(rest, " ") != "(x) b" {
+		t.Errorf("All: got %q, want [(x) b]", rest)
+	}
+	if _, ok := last.(*ScanError); !ok {
+		t.Errorf("All: got final error %v, want *ScanError", last)
+	}
+
+	// At EOF, yield is not called.
+	s = New(strings.NewReader("  "))
+	s.All(func(tok Token, err error) bool {
+		t.Errorf("All: unexpected call with %+v, %v", tok, err)
+		return true
+	})
+}
+
+func TestDecoded(t *testing.T) {
+	const input = `% note
+(a\(b\)) <66 6f 6f> <~AoDS~> 12 16#ff -1.5 name /quoted //immediate { }`
+	want := []string{"note", "a(b)", "foo", "foo", "12", "16#ff", "-1.5", "name", "quoted", "immediate", "{", "}"}
+
+	var i int
+	s := New(strings.NewReader(input))
+	s.All(func(tok Token, err error) bool {
+		if err != nil {
+			t.Fatalf("All: unexpected error: %v", err)
+		}
+		if got := tok.Decoded(); i >= len(want) || got != want[i] {
+			t.Errorf("Token %d %v %#q: Decoded() = %#q", i, tok.Type, tok.Text, got)
+		}
+		if got, direct := tok.Decoded(), s.String(); got != direct {
+			t.Errorf("Token %d: Decoded() = %#q, String() = %#q", i, got, direct)
+		}
+		i++
+		return true
+	})
+	if i != len(want) {
+		t.Errorf("Got %d tokens, want %d", i, len(want))
+	}
+
+	if got := (Token{}).Decoded(); got != "" {
+		t.Errorf("Decoded of invalid token: got %#q, want empty", got)
+	}
+
+	// Malformed literals decode as empty rather than panicking.
+	for _, tok := range []Token{
+		{Type: LitString}, {Type: LitString, Text: "("}, {Type: LitString, Text: "(a"},
+		{Type: HexString}, {Type: HexString, Text: "<"}, {Type: HexString, Text: "66>"},
+		{Type: A85String}, {Type: A85String, Text: "<~"}, {Type: A85String, Text: "<~>"},
+	} {
+		if got := tok.Decoded(); got != "" {
+			t.Errorf("Decoded of %v %#q: got %#q, want empty", tok.Type, tok.Text, got)
+		}
+	}
+}
+
+func TestConstructors(t *testing.T) {
+	const path = "testdata/corpus.ps"
+	input, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Reading test input: %v", err)
+	}
+	fs, closer, err := NewFromFile(path)
+	if err != nil {
+		t.Fatalf("NewFromFile: unexpected error: %v", err)
+	}
+	defer closer.Close()
+
+	collect := func(s *Scanner) []Token {
+		var toks []Token
+		s.All(func(tok Token, err error) bool {
+			if err != nil {
+				t.Fatalf("Scanning: unexpected error: %v", err)
+			}
+			toks = append(toks, tok)
+			return true
+		})
+		return toks
+	}
+	want := collect(New(strings.NewReader(string(input))))
+	for name, s := range map[string]*Scanner{
+		"NewFromBytes": NewFromBytes(input),
+		"NewFromFile":  fs,
+	} {
+		got := collect(s)
+		if len(got) != len(want) {
+			t.Errorf("%s: got %d tokens, want %d", name, len(got), len(want))
+			continue
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("%s: token %d: got %+v, want %+v", name, i, got[i], want[i])
+				break
+			}
+		}
+	}
+
+	if _, _, err := NewFromFile("testdata/nonexistent.ps"); err == nil {
+		t.Error("NewFromFile: got nil error for a missing file")
+	}
+}
+
+func TestLevel1(t *testing.T) {
+	tests := []struct {
+		input string
+		ok1   bool // whether level 1 accepts the input
+	}{
+		{"/Helvetica findfont 12 scalefont setfont", true},
+		{"0 0 moveto (hello) show showpage", true},
+		{"/setcolorspace (<<) <3c3c>", true},
+		{"<< /Type /Page >>", false},
+		{"/DeviceRGB setcolorspace", false},
+		{"1 1 8 [1 0 0 1 0 0] {} false 3 colorimage", false},
+		{"0 0 72 72 rectfill", false},
+	}
+	for _, test := range tests {
+		for _, level := range []int{1, 2} {
+			s := New(strings.NewReader(test.input)).SetLevel(level)
+			for s.Next() == nil {
+			}
+			err := s.Err()
+			if level == 1 && !test.ok1 {
+				if _, ok := err.(*ScanError); !ok {
+					t.Errorf("Level %d %#q: got %v, want *ScanError", level, test.input, err)
+				} else {
+					t.Logf("Level %d %#q: got %v [OK]", level, test.input, err)
+				}
+			} else if err != io.EOF {
+				t.Errorf("Level %d %#q: got %v, want EOF", level, test.input, err)
+			}
+		}
+	}
+
+	// NewLevel1 reports the offending token, and recovery mode can continue.
+	s := NewLevel1(strings.NewReader("1 << /a 2 >> 3")).ContinueOnError()
+	var got []string
+	for {
+		if err := s.Next(); err == io.EOF {
+			break
+		} else if err == nil {
+			got = append(got, s.Text())
+		}
+	}
+	if want := "1 /a 2 3"; strings.Join(got, " ") != want {
+		t.Errorf("Level 1 recovery: got %q, want %q", got, want)
+	}
+	if n := len(s.Errors()); n != 2 {
+		t.Errorf("Level 1 recovery: got %d errors, want 2", n)
+	}
+}
+
+func TestHexErrorMessage(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"<6g>", `offset 0: invalid hex character 'g' (0x67) in hex string at offset 2`},
+		{"x <\x01>", `offset 2: invalid hex character '\x01' (0x01) in hex string at offset 3`},
+		{"<\x7f>", `offset 0: invalid hex character '\x7f' (0x7f) in hex string at offset 1`},
+		{"<ab \xff>", `offset 0: invalid hex character 'ÿ' (0xff) in hex string at offset 4`},
+		{"<ab \x9b>", `offset 0: invalid hex character '\u009b' (0x9b) in hex string at offset 4`},
+	}
+	for _, test := range tests {
+		s := New(strings.NewReader(test.input))
+		for s.Next() == nil {
+		}
+		if got := s.Err().Error(); got != test.want {
+			t.Errorf("Scanning %#q:\n got %s\nwant %s", test.input, got, test.want)
+		}
+
+		// ReadHexString reports the same message.
+		s = New(strings.NewReader(test.input))
+		if strings.HasPrefix(test.input, "x") {
+			s.Next()
+		}
+		if _, err := s.ReadHexString(); err == nil || err.Error() != test.want {
+			t.Errorf("ReadHexString %#q:\n got %v\nwant %s", test.input, err, test.want)
+		}
+	}
+}
+
+func TestNeedSpaceBetween(t *testing.T) {
+	tests := []struct {
+		prev, next Type
+		want       bool
+	}{
+		{Decimal, Decimal, true},
+		{Decimal, Real, true},
+		{Real, Name, true},
+		{Radix, Name, true},
+		{Name, Name, true},
+		{Name, Decimal, true},
+		{QuotedName, Decimal, true},
+		{ImmediateName, Real, true},
+
+		{Left, Name, false},
+		{Name, Left, false},
+		{Right, Left, false},
+		{Name, QuotedName, false},
+		{QuotedName, QuotedName, false},
+		{HexString, Decimal, false},
+		{Decimal, HexString, false},
+		{A85String, Name, false},
+		{LitString, LitString, false},
+		{Decimal, LitString, false},
+
+		// Comments include their terminator, and "%" is a delimiter.
+		{Comment, Name, false},
+		{Comment, Comment, false},
+		{Name, Comment, false},
+		{Decimal, Comment, false},
+	}
+	for _, test := range tests {
+		if got := NeedSpaceBetween(test.prev, test.next); got != test.want {
+			t.Errorf("NeedSpaceBetween(%v, %v): got %v, want %v", test.prev, test.next, got, test.want)
 		}
 	}
 }
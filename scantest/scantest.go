@@ -0,0 +1,83 @@
+// Package scantest provides helpers for testing code that uses the scanner
+// package.
+package scantest
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/postscript/scanner"
+)
+
+// AssertTokens scans input and reports an error to t if the tokens produced
+// do not match want, or if scanning fails. Each token must match the Type and
+// Text of its counterpart in want; if End > 0, the token must also span
+// offsets Pos to End of the input.
+func AssertTokens(t testing.TB, input string, want []scanner.Token) {
+	t.Helper()
+
+	s := scanner.New(strings.NewReader(input))
+	var i int
+	for ; s.Next() == nil; i++ {
+		if i >= len(want) {
+			t.Errorf("Input %#q: extra token %d: %v %#q", input, i, s.Type(), s.Text())
+			continue
+		}
+		w := want[i]
+		if s.Type() != w.Type || s.Text() != w.Text {
+			t.Errorf("Input %#q: token %d: got %v %#q, want %v %#q", input, i, s.Type(), s.Text(), w.Type, w.Text)
+		}
+		if w.End > 0 && (s.Pos() != w.Pos || s.End() != w.End) {
+			t.Errorf("Input %#q: token %d: got span %d..%d, want %d..%d", input, i, s.Pos(), s.End(), w.Pos, w.End)
+		}
+	}
+	checkEOF(t, input, s)
+	if i < len(want) {
+		t.Errorf("Input %#q: got %d tokens, want %d", input, i, len(want))
+	}
+}
+
+// AssertDecodes scans input and reports an error to t if the decoded values
+// of the tokens produced, as given by the String method of the scanner, do
+// not match want, or if scanning fails.
+func AssertDecodes(t testing.TB, input string, want []string) {
+	t.Helper()
+
+	s := scanner.New(strings.NewReader(input))
+	var i int
+	for ; s.Next() == nil; i++ {
+		got := s.String()
+		if i >= len(want) {
+			t.Errorf("Input %#q: extra token %d: %v %#q", input, i, s.Type(), s.Text())
+		} else if got != want[i] {
+			t.Errorf("Input %#q: token %d: got %v %#q, want %#q", input, i, s.Type(), got, want[i])
+		}
+	}
+	checkEOF(t, input, s)
+	if i < len(want) {
+		t.Errorf("Input %#q: got %d tokens, want %d", input, i, len(want))
+	}
+}
+
+// AssertError scans input and reports an error to t unless scanning fails
+// with a *scanner.ScanError at the given byte offset.
+func AssertError(t testing.TB, input string, wantOffset int) {
+	t.Helper()
+
+	s := scanner.New(strings.NewReader(input))
+	for s.Next() == nil {
+	}
+	if se := s.ErrAt(); se == nil {
+		t.Errorf("Input %#q: got %v, wanted a scan error", input, s.Err())
+	} else if se.Pos != wantOffset {
+		t.Errorf("Input %#q: got error at %d (%v), want %d", input, se.Pos, se, wantOffset)
+	}
+}
+
+func checkEOF(t testing.TB, input string, s *scanner.Scanner) {
+	t.Helper()
+	if err := s.Err(); err != io.EOF {
+		t.Errorf("Input %#q: after scanning got %v, want EOF", input, err)
+	}
+}
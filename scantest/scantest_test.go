@@ -0,0 +1,101 @@
+package scantest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/creachadair/postscript/scanner"
+)
+
+// fakeT records the errors reported by a helper under test.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(msg string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(msg, args...))
+}
+
+func check(t *testing.T, f *fakeT, wantFail bool) {
+	t.Helper()
+	if failed := len(f.errors) != 0; failed != wantFail {
+		t.Errorf("Failed: got %v, want %v; errors: %q", failed, wantFail, f.errors)
+	}
+	for _, e := range f.errors {
+		t.Logf("Reported: %s", e)
+	}
+}
+
+func TestAssertTokens(t *testing.T) {
+	tests := []struct {
+		input    string
+		want     []scanner.Token
+		wantFail bool
+	}{
+		{"", nil, false},
+		{"/a 1", []scanner.Token{{Type: scanner.QuotedName, Text: "/a"}, {Type: scanner.Decimal, Text: "1"}}, false},
+		{" {x}", []scanner.Token{
+			{Type: scanner.Left, Text: "{", Pos: 1, End: 2},
+			{Type: scanner.Name, Text: "x", Pos: 2, End: 3},
+			{Type: scanner.Right, Text: "}", Pos: 3, End: 4},
+		}, false},
+
+		// Wrong type, text, or span.
+		{"1", []scanner.Token{{Type: scanner.Real, Text: "1"}}, true},
+		{"1", []scanner.Token{{Type: scanner.Decimal, Text: "2"}}, true},
+		{" 1", []scanner.Token{{Type: scanner.Decimal, Text: "1", Pos: 0, End: 1}}, true},
+
+		// Too many or too few tokens.
+		{"a b", []scanner.Token{{Type: scanner.Name, Text: "a"}}, true},
+		{"a", []scanner.Token{{Type: scanner.Name, Text: "a"}, {Type: scanner.Name, Text: "b"}}, true},
+
+		// Scan errors.
+		{"(a", nil, true},
+	}
+	for _, test := range tests {
+		f := &fakeT{TB: t}
+		AssertTokens(f, test.input, test.want)
+		check(t, f, test.wantFail)
+	}
+}
+
+func TestAssertDecodes(t *testing.T) {
+	tests := []struct {
+		input    string
+		want     []string
+		wantFail bool
+	}{
+		{"", nil, false},
+		{"(a\\)b) <66 6f 6f> /x", []string{"a)b", "foo", "x"}, false},
+		{"(a)", []string{"(a)"}, true},
+		{"a b", []string{"a"}, true},
+		{"a", []string{"a", "b"}, true},
+		{"a <zz>", []string{"a"}, true},
+	}
+	for _, test := range tests {
+		f := &fakeT{TB: t}
+		AssertDecodes(f, test.input, test.want)
+		check(t, f, test.wantFail)
+	}
+}
+
+func TestAssertError(t *testing.T) {
+	tests := []struct {
+		input    string
+		offset   int
+		wantFail bool
+	}{
+		{"a b (c", 4, false},
+		{"<~ AoDS", 0, false},
+		{"a b (c", 0, true},
+		{"a b c", 0, true},
+	}
+	for _, test := range tests {
+		f := &fakeT{TB: t}
+		AssertError(f, test.input, test.offset)
+		check(t, f, test.wantFail)
+	}
+}